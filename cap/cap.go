@@ -120,6 +120,14 @@ func Isatty() bool {
 	return CapTTY.Isatty()
 }
 
+// StderrIsatty indicates whether stderr of program is a terminal.
+func StderrIsatty() bool {
+	if config.MockNoTTY() {
+		return false
+	}
+	return isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
+}
+
 // GitCanPushOptions indicates whether git can sent push options.
 func GitCanPushOptions() bool {
 	return CapGit.GitCanPushOptions()
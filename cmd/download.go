@@ -16,8 +16,10 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/alibaba/git-repo-go/config"
 	"github.com/alibaba/git-repo-go/helper"
@@ -34,11 +36,20 @@ type downloadCommand struct {
 		CherryPick bool
 		Revert     bool
 		FFOnly     bool
+		Topic      string
+		Interdiff  string
+		Worktree   string
+		Cleanup    bool
+		Force      bool
 		NoCache    bool
 		Remote     string
 	}
 }
 
+// defaultWorktree is the value of --worktree when no dir is given, and a
+// worktree is created next to the project.
+const defaultWorktree = "-"
+
 // projectChange wraps download project and review ID
 type projectChange struct {
 	Project  *project.Project
@@ -47,7 +58,9 @@ type projectChange struct {
 }
 
 var (
-	reChange = regexp.MustCompile(`^([1-9][0-9]*)(?:[/\. -]([1-9][0-9]*))?$`)
+	reChange    = regexp.MustCompile(`^([1-9][0-9]*)(?:[/\. -]([1-9][0-9]*))?$`)
+	reChangeID  = regexp.MustCompile(`^I[0-9a-f]{40}$`)
+	reInterdiff = regexp.MustCompile(`^([1-9][0-9]*)/([1-9][0-9]*)\.\.([1-9][0-9]*)$`)
 )
 
 func (v *downloadCommand) Command() *cobra.Command {
@@ -56,8 +69,14 @@ func (v *downloadCommand) Command() *cobra.Command {
 	}
 
 	v.cmd = &cobra.Command{
-		Use:   "download",
+		Use:   "download [[<project>] <id>[/<patch>] | <url> | <Change-Id>]...",
 		Short: "Download and checkout a code review",
+		Long: `Download and checkout code reviews, which can be given by review ID
+and patch ID of a project, by web URL of code review, or by Change-Id.
+Use --topic to download all open code reviews of a topic.
+
+Use --interdiff <id>/<ps1>..<ps2> to show changes between two patch sets
+of a code review.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return v.Execute(args)
 		},
@@ -77,6 +96,28 @@ func (v *downloadCommand) Command() *cobra.Command {
 		"f",
 		false,
 		"force fast-forward merge")
+	v.cmd.Flags().StringVar(&v.O.Topic,
+		"topic",
+		"",
+		"download all open code reviews of topic")
+	v.cmd.Flags().StringVar(&v.O.Interdiff,
+		"interdiff",
+		"",
+		"show changes between patch sets, e.g.: <id>/<ps1>..<ps2>")
+	v.cmd.Flags().StringVarP(&v.O.Worktree,
+		"worktree",
+		"w",
+		"",
+		"checkout in a new worktree at dir (default: <project>-review-<id>-<patch>)")
+	v.cmd.Flags().Lookup("worktree").NoOptDefVal = defaultWorktree
+	v.cmd.Flags().BoolVar(&v.O.Cleanup,
+		"cleanup",
+		false,
+		"remove worktrees created by download --worktree")
+	v.cmd.Flags().BoolVar(&v.O.Force,
+		"force",
+		false,
+		"used with --cleanup, also remove worktrees with uncommitted changes")
 	v.cmd.Flags().BoolVar(&v.O.NoCache,
 		"no-cache",
 		false,
@@ -93,9 +134,44 @@ func (v *downloadCommand) parseChanges(args ...string) ([]projectChange, error)
 	var (
 		changes []projectChange
 		p       *project.Project
+		err     error
 	)
 
 	for _, arg := range args {
+		if u := helper.ParseReviewURL(arg); u != nil {
+			c, err := v.reviewURLChange(u)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, *c)
+			continue
+		}
+
+		if reChangeID.MatchString(arg) {
+			projects := []*project.Project{p}
+			if p == nil {
+				projects, err = v.ws.GetProjects(nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+			found, err := v.queryChanges(projects, &helper.ReviewQuery{
+				ChangeID: arg,
+				Status:   helper.ReviewStatusAll,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("cannot find code review of Change-Id %s", arg)
+			} else if len(found) > 1 {
+				return nil, fmt.Errorf("Change-Id %s matches %d code reviews, use review ID instead",
+					arg, len(found))
+			}
+			changes = append(changes, found...)
+			continue
+		}
+
 		matches := reChange.FindStringSubmatch(arg)
 		if matches == nil || p == nil {
 			projectName := arg
@@ -125,7 +201,187 @@ func (v *downloadCommand) parseChanges(args ...string) ([]projectChange, error)
 	return changes, nil
 }
 
+// matchReviewProject finds project of code review from projects, whose
+// name or repository path of remote URL is name. Project on host is
+// preferred if more than one projects are matched.
+func (v *downloadCommand) matchReviewProject(projects []*project.Project, host, name string) *project.Project {
+	var matched *project.Project
+
+	name = strings.Trim(strings.TrimSuffix(name, ".git"), "/")
+	host = strings.Split(host, ":")[0]
+	for _, p := range projects {
+		var remote *project.Remote
+		if v.O.Remote != "" {
+			remote = p.Remotes.Get(v.O.Remote)
+		} else {
+			remote = p.GetDefaultRemote(true)
+		}
+		if remote == nil {
+			continue
+		}
+		u := config.ParseGitURL(p.GitConfigRemoteURL(remote.Name))
+		if p.Name != name && (u == nil || strings.Trim(strings.TrimSuffix(u.Repo, ".git"), "/") != name) {
+			continue
+		}
+		if host == "" {
+			return p
+		}
+		if u != nil && u.Host == host {
+			return p
+		}
+		if r := config.ParseGitURL(reviewRootURL(remote.Review)); r != nil && r.Host == host {
+			return p
+		}
+		if matched == nil {
+			matched = p
+		}
+	}
+	return matched
+}
+
+// reviewURLChange returns change of web URL of code review.
+func (v *downloadCommand) reviewURLChange(u *helper.ReviewURL) (*projectChange, error) {
+	projects, err := v.ws.GetProjects(nil)
+	if err != nil {
+		return nil, err
+	}
+	p := v.matchReviewProject(projects, u.Host, u.Project)
+	if p == nil {
+		return nil, fmt.Errorf("cannot find project '%s' of code review #%s in workspace",
+			u.Project, u.ID)
+	}
+	c := projectChange{Project: p}
+	c.ReviewID, _ = strconv.Atoi(u.ID)
+	c.PatchID, _ = strconv.Atoi(u.Patch)
+	return &c, nil
+}
+
+// queryChanges queries code reviews matching conditions of cond from
+// review servers of projects, and returns changes of these projects.
+func (v *downloadCommand) queryChanges(projects []*project.Project, cond *helper.ReviewQuery) ([]projectChange, error) {
+	var (
+		changes []projectChange
+		lastErr error
+		found   = make(map[string]bool)
+	)
+
+	for _, p := range projects {
+		querier, q, err := newReviewQuerier(p, v.O.Remote)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		// Projects may share the same review server.
+		if found[q.URL] {
+			continue
+		}
+		found[q.URL] = true
+
+		host := ""
+		if u := config.ParseGitURL(q.URL); u != nil {
+			host = u.Host
+		}
+		q.Project = ""
+		q.Topic = cond.Topic
+		q.ChangeID = cond.ChangeID
+		q.Status = cond.Status
+		reviews, err := querier.ListReviews(q)
+		if err != nil {
+			return nil, fmt.Errorf("%sfail to query code reviews: %s", p.Prompt(), err)
+		}
+		for _, review := range reviews {
+			rp := v.matchReviewProject(projects, host, review.Project)
+			if rp == nil {
+				log.Warnf("cannot find project '%s' of code review #%s in workspace",
+					review.Project, review.ID)
+				continue
+			}
+			c := projectChange{Project: rp}
+			c.ReviewID, _ = strconv.Atoi(review.ID)
+			c.PatchID, _ = strconv.Atoi(review.Patch)
+			changes = append(changes, c)
+		}
+	}
+	if len(found) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return changes, nil
+}
+
+// reviewWorktreeDir returns path of worktree for change of project.
+func (v *downloadCommand) reviewWorktreeDir(p *project.Project, changeID string) (string, error) {
+	if v.O.Worktree != defaultWorktree {
+		return filepath.Abs(v.O.Worktree)
+	}
+	return filepath.Clean(p.WorkDir) + "-review-" + strings.Replace(changeID, "/", "-", -1), nil
+}
+
+// cleanupWorktrees removes worktrees created for code reviews.
+func (v *downloadCommand) cleanupWorktrees(args []string) error {
+	projects, err := v.WorkSpace().GetProjects(nil, args...)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, p := range projects {
+		removed, skipped, err := p.RemoveReviewWorktrees(v.O.Force)
+		for _, dir := range removed {
+			log.Notef("[%s] removed worktree %s", p.Name, dir)
+		}
+		for _, dir := range skipped {
+			log.Warnf("[%s] skip worktree %s with uncommitted changes, use --force to remove",
+				p.Name, dir)
+		}
+		count += len(removed) + len(skipped)
+		if err != nil {
+			return err
+		}
+	}
+	if count == 0 {
+		log.Note("no review worktrees to remove")
+	}
+	return nil
+}
+
+// showInterdiff shows changes between two patch sets of code review of
+// project given in args.
+func (v *downloadCommand) showInterdiff(args []string) error {
+	m := reInterdiff.FindStringSubmatch(v.O.Interdiff)
+	if m == nil {
+		return fmt.Errorf("bad interdiff '%s', should be <id>/<ps1>..<ps2>", v.O.Interdiff)
+	}
+	if len(args) > 1 {
+		return newUserError("only one project can be given for --interdiff")
+	}
+	projectName := "."
+	if len(args) > 0 {
+		projectName = args[0]
+	}
+	projects, err := v.ws.GetProjects(nil, projectName)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("cannot find project matched for '%s'", projectName)
+	}
+
+	reviewID, _ := strconv.Atoi(m[1])
+	patch1, _ := strconv.Atoi(m[2])
+	patch2, _ := strconv.Atoi(m[3])
+	return projects[0].Interdiff(v.O.Remote, reviewID, patch1, patch2)
+}
+
 func (v *downloadCommand) Execute(args []string) error {
+	if v.O.Cleanup {
+		if v.O.Worktree != "" || v.O.CherryPick || v.O.Revert || v.O.FFOnly {
+			return fmt.Errorf("cannot use --cleanup with other download options")
+		}
+		return v.cleanupWorktrees(args)
+	}
+	if v.O.Force {
+		return fmt.Errorf("--force can only be used with --cleanup")
+	}
+
 	ws := v.WorkSpace()
 	err := ws.LoadRemotes(v.O.NoCache)
 	if err != nil {
@@ -145,18 +401,47 @@ func (v *downloadCommand) Execute(args []string) error {
 	if n > 1 {
 		return fmt.Errorf("cannot use more than one of `-c`, `-r`, or `-f` options")
 	}
+	if n > 0 && v.O.Worktree != "" {
+		return fmt.Errorf("cannot use --worktree with `-c`, `-r`, or `-f` options")
+	}
 
 	if v.O.Remote != "" && !config.IsSingleMode() {
 		return fmt.Errorf("--remote can be only used with --single")
 	}
 
-	if len(args) == 0 {
-		return newUserError("no args")
+	if v.O.Interdiff != "" {
+		if n > 0 || v.O.Worktree != "" || v.O.Topic != "" {
+			return fmt.Errorf("cannot use --interdiff with other download options")
+		}
+		return v.showInterdiff(args)
 	}
 
-	changes, err := v.parseChanges(args...)
-	if err != nil {
-		return err
+	var changes []projectChange
+	if v.O.Topic != "" {
+		projects, err := ws.GetProjects(nil, args...)
+		if err != nil {
+			return err
+		}
+		changes, err = v.queryChanges(projects, &helper.ReviewQuery{
+			Topic:  v.O.Topic,
+			Status: helper.ReviewStatusOpen,
+		})
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return fmt.Errorf("cannot find open code reviews of topic '%s'", v.O.Topic)
+		}
+	} else if len(args) == 0 {
+		return newUserError("no args")
+	} else {
+		changes, err = v.parseChanges(args...)
+		if err != nil {
+			return err
+		}
+	}
+	if len(changes) > 1 && v.O.Worktree != "" && v.O.Worktree != defaultWorktree {
+		return fmt.Errorf("cannot download more than one change into worktree '%s'", v.O.Worktree)
 	}
 
 	for _, c := range changes {
@@ -172,6 +457,20 @@ func (v *downloadCommand) Execute(args []string) error {
 			changeID = fmt.Sprintf("%d/%d", c.ReviewID, c.PatchID)
 		}
 
+		if v.O.Worktree != "" {
+			dir, err := v.reviewWorktreeDir(c.Project, changeID)
+			if err != nil {
+				return err
+			}
+			err = c.Project.AddReviewWorktree(dir, dl, changeID)
+			if err != nil {
+				return err
+			}
+			log.Notef("[%s] change %s is checked out in worktree %s",
+				c.Project.Name, changeID, dir)
+			continue
+		}
+
 		if len(dl.Commits) == 0 && !v.O.Revert {
 			log.Notef("[%s] change %s has already been merged",
 				c.Project.Name, changeID)
@@ -70,7 +70,7 @@ func (v *forallCommand) Command() *cobra.Command {
 		"groups",
 		"g",
 		"",
-		"Execute the command only on projects matching the specified groups")
+		"Execute the command only on projects matching the specified groups, e.g.: G1,-G2 or (G1|G2)&!G3")
 	v.cmd.Flags().StringVarP(&v.O.Command,
 		"command",
 		"c",
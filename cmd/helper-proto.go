@@ -27,10 +27,12 @@ import (
 type helperProtoCommand struct {
 	cmd *cobra.Command
 	O   struct {
-		Upload   bool
-		Download bool
-		Type     string
-		Version  int
+		Upload       bool
+		Download     bool
+		Capabilities bool
+		Type         string
+		Version      int
+		Protocol     int
 	}
 }
 
@@ -55,6 +57,10 @@ func (v *helperProtoCommand) Command() *cobra.Command {
 		"version",
 		0,
 		"version of protocol")
+	v.cmd.Flags().IntVar(&v.O.Protocol,
+		"protocol",
+		helper.ProtoHelperProtocol,
+		"version of protocol between git-repo and helper")
 	v.cmd.Flags().BoolVar(&v.O.Upload,
 		"upload",
 		false,
@@ -63,6 +69,10 @@ func (v *helperProtoCommand) Command() *cobra.Command {
 		"download",
 		false,
 		"output JSON for download git reference")
+	v.cmd.Flags().BoolVar(&v.O.Capabilities,
+		"capabilities",
+		false,
+		"output JSON for capabilities of helper")
 
 	return v.cmd
 }
@@ -82,8 +92,27 @@ func (v *helperProtoCommand) Execute(arts []string) error {
 	sshInfo := helper.SSHInfo{ProtoType: v.O.Type, ProtoVersion: v.O.Version}
 	protoHelper = helper.NewProtoHelper(&sshInfo)
 
-	if v.O.Download && v.O.Upload {
-		return fmt.Errorf("cannot use --download and --upload together")
+	if v.O.Download && v.O.Upload ||
+		v.O.Capabilities && (v.O.Download || v.O.Upload) {
+		return fmt.Errorf("cannot use --download, --upload and --capabilities together")
+	}
+
+	if v.O.Capabilities {
+		buf, err = helper.GetCapabilitiesPipe(protoHelper)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(buf))
+		return nil
+	}
+
+	if v.O.Download && v.O.Protocol >= helper.ProtoHelperProtocolV2 {
+		buf, err = helper.GetDownloadRefOptionsPipe(protoHelper)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(buf))
+		return nil
 	}
 
 	if v.O.Download {
@@ -112,7 +141,7 @@ func (v *helperProtoCommand) Execute(arts []string) error {
 		return nil
 	}
 
-	buf, err = helper.GetGitPushCommandPipe(protoHelper)
+	buf, err = helper.GetGitPushCommandPipe(protoHelper, v.O.Protocol)
 	if err != nil {
 		return err
 	}
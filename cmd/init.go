@@ -123,7 +123,7 @@ func (v *initCommand) Command() *cobra.Command {
 		"groups",
 		"g",
 		"default",
-		"restrict manifest projects to ones with specified group(s) [default|all|G1,G2,G3|G4,-G5,-G6]")
+		"restrict manifest projects to ones with specified group(s) [default|all|G1,G2,G3|G4,-G5,-G6|(G1|G2)&!G3]")
 	v.cmd.Flags().StringVarP(&v.O.Platform,
 		"platform",
 		"p",
@@ -193,6 +193,10 @@ func (v initCommand) Execute(args []string) error {
 		log.Fatal("--mirror and --archive cannot be used together")
 	}
 
+	if err = project.ValidateGroups(v.O.Groups); err != nil {
+		return newUserError(err)
+	}
+
 	if config.IsSingleMode() {
 		log.Fatal("cannot run in single mode")
 	}
@@ -62,7 +62,7 @@ func (v *listCommand) Command() *cobra.Command {
 		"groups",
 		"g",
 		"",
-		"Filter the project list based on the groups the project is in")
+		"Filter the project list based on the groups the project is in, e.g.: G1,-G2 or (G1|G2)&!G3")
 	v.cmd.Flags().BoolVarP(&v.O.FullPath,
 		"fullpath",
 		"f",
@@ -0,0 +1,122 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/spf13/cobra"
+)
+
+type localManifestAddProjectCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Path       string
+		Remote     string
+		Revision   string
+		Groups     string
+		DestBranch string
+		Upstream   string
+	}
+}
+
+func (v *localManifestAddProjectCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "add-project <name>",
+		Short: "Add a new project in local manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	v.cmd.Flags().StringVar(&v.O.Path,
+		"path",
+		"",
+		"path of the project, default is the same as name")
+	v.cmd.Flags().StringVar(&v.O.Remote,
+		"remote",
+		"",
+		"remote of the project")
+	v.cmd.Flags().StringVar(&v.O.Revision,
+		"revision",
+		"",
+		"revision of the project")
+	v.cmd.Flags().StringVar(&v.O.Groups,
+		"groups",
+		"",
+		"groups the project belongs to")
+	v.cmd.Flags().StringVar(&v.O.DestBranch,
+		"dest-branch",
+		"",
+		"destination branch for code review")
+	v.cmd.Flags().StringVar(&v.O.Upstream,
+		"upstream",
+		"",
+		"name of the git ref in which a sha1 can be found")
+
+	return v.cmd
+}
+
+func (v localManifestAddProjectCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return newUserError("only one project name should be given")
+	}
+	name := args[0]
+	path := v.O.Path
+	if path == "" {
+		path = name
+	}
+
+	local, err := localManifestCmd.Load()
+	if err != nil {
+		return err
+	}
+	if findLocalProject(local, name, path) >= 0 {
+		return fmt.Errorf("project '%s' is already defined in '%s'",
+			name,
+			local.SourceFile)
+	}
+
+	// Remove-project is applied on projects of the same manifest file.
+	for _, p := range local.RemoveProjects {
+		if p.Match(&manifest.Project{Name: name, Path: path}) {
+			return fmt.Errorf("project '%s' is removed in '%s', use another local manifest by --name",
+				name,
+				local.SourceFile)
+		}
+	}
+
+	local.Projects = append(local.Projects, manifest.Project{
+		Name:       name,
+		Path:       v.O.Path,
+		RemoteName: v.O.Remote,
+		Revision:   v.O.Revision,
+		Groups:     v.O.Groups,
+		DestBranch: v.O.DestBranch,
+		Upstream:   v.O.Upstream,
+	})
+
+	return localManifestCmd.Save(local, name)
+}
+
+var localManifestAddProjectCmd = localManifestAddProjectCommand{}
+
+func init() {
+	localManifestCmd.Command().AddCommand(localManifestAddProjectCmd.Command())
+}
@@ -0,0 +1,135 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/alibaba/git-repo-go/project"
+	"github.com/spf13/cobra"
+)
+
+type localManifestExtendProjectCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Groups   string
+		Revision string
+	}
+}
+
+func (v *localManifestExtendProjectCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "extend-project <name|path>...",
+		Short: "Extend attributes of projects using local manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	v.cmd.Flags().StringVar(&v.O.Groups,
+		"groups",
+		"",
+		"additional groups the project belongs to")
+	v.cmd.Flags().StringVar(&v.O.Revision,
+		"revision",
+		"",
+		"change revision of the project")
+
+	return v.cmd
+}
+
+// extendLocalProject changes groups and revision of project p in local
+// manifest. If project p is defined in local manifest, changes it directly,
+// otherwise add or update an extend-project element.
+func extendLocalProject(local *manifest.Manifest, p *project.Project, groups, revision string) {
+	if i := findLocalProject(local, p.Name, p.Path); i >= 0 {
+		lp := &local.Projects[i]
+		if groups != "" {
+			lp.Groups = joinGroups(lp.Groups, groups)
+		}
+		if revision != "" {
+			lp.Revision = revision
+		}
+		return
+	}
+
+	i := findLocalExtendProject(local, p.Name, p.Path)
+	if i < 0 {
+		ep := manifest.ExtendProject{Name: p.Name}
+		// Path of extend-project should match path of the project.
+		if p.Path != p.Name {
+			ep.Path = p.Path
+		}
+		local.ExtendProjects = append(local.ExtendProjects, ep)
+		i = len(local.ExtendProjects) - 1
+	}
+	ep := &local.ExtendProjects[i]
+	if groups != "" {
+		ep.Groups = joinGroups(ep.Groups, groups)
+	}
+	if revision != "" {
+		ep.Revision = revision
+	}
+}
+
+// joinGroups appends new groups to comma separated groups, ignore duplicates.
+func joinGroups(groups, newGroups string) string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, g := range strings.Split(groups+","+newGroups, ",") {
+		g = strings.TrimSpace(g)
+		if g == "" || seen[g] {
+			continue
+		}
+		seen[g] = true
+		result = append(result, g)
+	}
+	return strings.Join(result, ",")
+}
+
+func (v localManifestExtendProjectCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		return newUserError("no project to extend")
+	}
+	if v.O.Groups == "" && v.O.Revision == "" {
+		return newUserError("nothing to extend, use --groups or --revision")
+	}
+
+	projects, err := localManifestCmd.GetProjects(args...)
+	if err != nil {
+		return err
+	}
+
+	local, err := localManifestCmd.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		extendLocalProject(local, p, v.O.Groups, v.O.Revision)
+	}
+
+	return localManifestCmd.Save(local, args...)
+}
+
+var localManifestExtendProjectCmd = localManifestExtendProjectCommand{}
+
+func init() {
+	localManifestCmd.Command().AddCommand(localManifestExtendProjectCmd.Command())
+}
@@ -0,0 +1,107 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/spf13/cobra"
+)
+
+type localManifestListCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *localManifestListCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List local manifests and their elements",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+// formatAttrs formats non-empty key/value pairs, with a leading space.
+func formatAttrs(attrs ...string) string {
+	items := []string{""}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] != "" {
+			items = append(items, attrs[i]+"="+attrs[i+1])
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+func (v localManifestListCommand) Execute(args []string) error {
+	ws := localManifestCmd.RepoWorkSpace()
+	for _, file := range manifest.LocalManifestFiles(ws.AdminDir()) {
+		m, err := manifest.LoadLocalManifest(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(ws.AdminDir(), file)
+		if err != nil {
+			rel = file
+		}
+		fmt.Println(filepath.ToSlash(rel))
+		for _, r := range m.Remotes {
+			fmt.Printf("  %-15s %s%s\n", "remote", r.Name,
+				formatAttrs("fetch", r.Fetch, "review", r.Review, "revision", r.Revision))
+		}
+		for _, p := range m.Projects {
+			fmt.Printf("  %-15s %s%s\n", "project", p.Name,
+				formatAttrs(
+					"path", p.Path,
+					"remote", p.RemoteName,
+					"revision", p.Revision,
+					"groups", p.Groups,
+					"dest-branch", p.DestBranch,
+					"upstream", p.Upstream))
+		}
+		for _, p := range m.RemoveProjects {
+			fmt.Printf("  %-15s %s%s\n", "remove-project", p.Name,
+				formatAttrs(
+					"path", p.Path,
+					"optional", p.Optional))
+		}
+		for _, p := range m.ExtendProjects {
+			fmt.Printf("  %-15s %s%s\n", "extend-project", p.Name,
+				formatAttrs(
+					"path", p.Path,
+					"groups", p.Groups,
+					"revision", p.Revision,
+					"remote", p.RemoteName,
+					"dest-branch", p.DestBranch,
+					"upstream", p.Upstream))
+		}
+	}
+	return nil
+}
+
+var localManifestListCmd = localManifestListCommand{}
+
+func init() {
+	localManifestCmd.Command().AddCommand(localManifestListCmd.Command())
+}
@@ -0,0 +1,94 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/spf13/cobra"
+)
+
+type localManifestRemoveProjectCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *localManifestRemoveProjectCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "remove-project <name|path>...",
+		Short: "Remove projects using local manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v localManifestRemoveProjectCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		return newUserError("no project to remove")
+	}
+
+	projects, err := localManifestCmd.GetProjects(args...)
+	if err != nil {
+		return err
+	}
+
+	local, err := localManifestCmd.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		// Project defined in this local manifest, remove it directly.
+		if i := findLocalProject(local, p.Name, p.Path); i >= 0 {
+			local.Projects = append(local.Projects[:i], local.Projects[i+1:]...)
+			continue
+		}
+
+		if i := findLocalExtendProject(local, p.Name, p.Path); i >= 0 {
+			local.ExtendProjects = append(local.ExtendProjects[:i],
+				local.ExtendProjects[i+1:]...)
+		}
+
+		rp := manifest.RemoveProject{Name: p.Name}
+		// Limit to the project checked out at the path.
+		if p.Path != p.Name {
+			rp.Path = p.Path
+		}
+		found := false
+		for _, r := range local.RemoveProjects {
+			if r.Match(&p.Project) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			local.RemoveProjects = append(local.RemoveProjects, rp)
+		}
+	}
+
+	// Removed projects cannot be synced by name, sync all projects.
+	return localManifestCmd.Save(local)
+}
+
+var localManifestRemoveProjectCmd = localManifestRemoveProjectCommand{}
+
+func init() {
+	localManifestCmd.Command().AddCommand(localManifestRemoveProjectCmd.Command())
+}
@@ -0,0 +1,70 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type localManifestSetRevisionCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *localManifestSetRevisionCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "set-revision <name|path> <revision>",
+		Short: "Change revision of project using local manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v localManifestSetRevisionCommand) Execute(args []string) error {
+	if len(args) != 2 {
+		return newUserError("should provide project and revision")
+	}
+	if args[1] == "" {
+		return newUserError("empty revision")
+	}
+
+	projects, err := localManifestCmd.GetProjects(args[0])
+	if err != nil {
+		return err
+	}
+
+	local, err := localManifestCmd.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		extendLocalProject(local, p, "", args[1])
+	}
+
+	return localManifestCmd.Save(local, args[0])
+}
+
+var localManifestSetRevisionCmd = localManifestSetRevisionCommand{}
+
+func init() {
+	localManifestCmd.Command().AddCommand(localManifestSetRevisionCmd.Command())
+}
@@ -0,0 +1,140 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/alibaba/git-repo-go/project"
+	"github.com/alibaba/git-repo-go/workspace"
+	log "github.com/jiangxin/multi-log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultLocalManifestName is name of the local manifest to edit.
+	defaultLocalManifestName = "local"
+)
+
+type localManifestCommand struct {
+	WorkSpaceCommand
+
+	cmd *cobra.Command
+	O   struct {
+		Name string
+		Sync bool
+	}
+}
+
+func (v *localManifestCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "local-manifest <subcommand>",
+		Short: "Edit local manifests in .repo/local_manifests",
+	}
+	v.cmd.PersistentFlags().StringVarP(&v.O.Name,
+		"name",
+		"n",
+		defaultLocalManifestName,
+		"name of the local manifest file to edit")
+	v.cmd.PersistentFlags().BoolVar(&v.O.Sync,
+		"sync",
+		false,
+		"run sync for the affected projects after editing")
+
+	return v.cmd
+}
+
+// File returns full path of the local manifest to edit.
+func (v *localManifestCommand) File() string {
+	return manifest.LocalManifestFile(v.RepoWorkSpace().AdminDir(), v.O.Name)
+}
+
+// Load reads the local manifest to edit.
+func (v *localManifestCommand) Load() (*manifest.Manifest, error) {
+	return manifest.LoadLocalManifest(v.File())
+}
+
+// Save validates the local manifest against the merged manifest and save it.
+// If --sync is given, run sync on projects provided in args.
+func (v *localManifestCommand) Save(local *manifest.Manifest, args ...string) error {
+	ws := v.RepoWorkSpace()
+	_, err := manifest.LoadWithLocalManifest(ws.AdminDir(), local)
+	if err != nil {
+		return fmt.Errorf("invalid local manifest, not saved: %s", err)
+	}
+	err = manifest.SaveLocalManifest(local)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(ws.AdminDir(), local.SourceFile)
+	if err != nil {
+		rel = local.SourceFile
+	}
+	log.Notef("saved local manifest to %s", filepath.ToSlash(rel))
+
+	if !v.O.Sync {
+		return nil
+	}
+	return syncCmd.Execute(args)
+}
+
+// GetProjects returns projects in workspace matched with name or path.
+func (v *localManifestCommand) GetProjects(args ...string) ([]*project.Project, error) {
+	return v.RepoWorkSpace().GetProjects(&workspace.GetProjectsOptions{
+		Groups:    "all",
+		MissingOK: true,
+	}, args...)
+}
+
+// findLocalProject returns index of project defined in local manifest.
+func findLocalProject(local *manifest.Manifest, name, path string) int {
+	for i, p := range local.Projects {
+		pPath := p.Path
+		if pPath == "" {
+			pPath = p.Name
+		}
+		if p.Name == name && (path == "" || pPath == path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findLocalExtendProject returns index of extend-project in local manifest.
+func findLocalExtendProject(local *manifest.Manifest, name, path string) int {
+	for i, p := range local.ExtendProjects {
+		if p.Name == name && (p.Path == "" || p.Path == path) {
+			return i
+		}
+	}
+	return -1
+}
+
+var localManifestCmd = localManifestCommand{
+	WorkSpaceCommand: WorkSpaceCommand{
+		MirrorOK: true,
+		SingleOK: false,
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localManifestCmd.Command())
+}
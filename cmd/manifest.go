@@ -32,6 +32,7 @@ type manifestCommand struct {
 		PegRev           bool
 		PegRevNoUpstream bool
 		OutputFile       string
+		Format           string
 	}
 }
 
@@ -64,6 +65,10 @@ func (v *manifestCommand) Command() *cobra.Command {
 		"o",
 		"-",
 		"File to save the manifest to")
+	v.cmd.Flags().StringVar(&v.O.Format,
+		"format",
+		manifest.FormatXML,
+		"Format of the manifest: xml, json or yaml")
 
 	return v.cmd
 }
@@ -78,7 +83,14 @@ func (v manifestCommand) WriteManifest(writer io.Writer) error {
 		}
 	}
 
-	data, err := manifest.Marshal(ws.Manifest)
+	if v.O.Format != manifest.FormatXML {
+		err := ws.ResolveManifest()
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := manifest.MarshalFormat(ws.Manifest, v.O.Format)
 	if err != nil {
 		return err
 	}
@@ -101,6 +113,13 @@ func (v manifestCommand) Execute(args []string) error {
 		writer io.ReadWriteCloser
 	)
 
+	switch v.O.Format {
+	case manifest.FormatXML, manifest.FormatJSON, manifest.FormatYAML:
+	default:
+		return newUserErrorF("unknown format '%s', should be one of: xml, json, yaml",
+			v.O.Format)
+	}
+
 	if v.O.OutputFile == "" {
 		log.Fatal("no output file, no operation to perform")
 	} else if v.O.OutputFile == "-" {
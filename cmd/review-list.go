@@ -0,0 +1,138 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alibaba/git-repo-go/helper"
+	log "github.com/jiangxin/multi-log"
+	"github.com/spf13/cobra"
+)
+
+type reviewListCommand struct {
+	cmd *cobra.Command
+	O   struct {
+		Mine   bool
+		Status string
+		Limit  int
+	}
+}
+
+func (v *reviewListCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "list [<project>...]",
+		Short: "List code reviews of projects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+	v.cmd.Flags().BoolVar(&v.O.Mine,
+		"mine",
+		false,
+		"only list code reviews owned by me")
+	v.cmd.Flags().StringVar(&v.O.Status,
+		"status",
+		helper.ReviewStatusOpen,
+		"status of code reviews: open, merged, closed or all")
+	v.cmd.Flags().IntVarP(&v.O.Limit,
+		"limit",
+		"n",
+		0,
+		"max number of code reviews for each project")
+
+	return v.cmd
+}
+
+func (v reviewListCommand) Execute(args []string) error {
+	ws := reviewCmd.WorkSpace()
+	err := ws.LoadRemotes(reviewCmd.O.NoCache)
+	if err != nil {
+		return err
+	}
+
+	allProjects, err := ws.GetProjects(nil, args...)
+	if err != nil {
+		return err
+	}
+
+	result := []helper.ReviewInfo{}
+	found := make(map[string]bool)
+	for _, p := range allProjects {
+		querier, q, err := reviewCmd.reviewQuerier(p)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
+		// Projects may share the same repository.
+		key := q.URL + "\x00" + q.Project
+		if found[key] {
+			continue
+		}
+		found[key] = true
+
+		q.Status = v.O.Status
+		q.Limit = v.O.Limit
+		if v.O.Mine {
+			m := helper.UserEmailPattern.FindStringSubmatch(p.UserEmail())
+			if m == nil {
+				return fmt.Errorf("cannot find email of current user")
+			}
+			q.Owner = m[2] + "@" + m[3]
+		}
+		reviews, err := querier.ListReviews(q)
+		if err != nil {
+			return fmt.Errorf("%sfail to list code reviews: %s", p.Prompt(), err)
+		}
+		if reviewCmd.O.JSON {
+			result = append(result, reviews...)
+			continue
+		}
+		if len(reviews) == 0 {
+			continue
+		}
+		fmt.Printf("project %s/:\n", p.Path)
+		for _, review := range reviews {
+			fmt.Printf("  #%-7s %-7s %-15s %s\n",
+				review.ID,
+				review.Status,
+				review.Branch,
+				review.Subject)
+		}
+		result = append(result, reviews...)
+	}
+
+	if reviewCmd.O.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else if len(result) == 0 {
+		log.Note("no code reviews found")
+	}
+	return nil
+}
+
+var reviewListCmd = reviewListCommand{}
+
+func init() {
+	reviewCmd.Command().AddCommand(reviewListCmd.Command())
+}
@@ -0,0 +1,111 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+type reviewShowCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *reviewShowCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "show [<project>] <id>",
+		Short: "Show status and latest patch set of a code review",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v reviewShowCommand) Execute(args []string) error {
+	var projectName, id string
+
+	switch len(args) {
+	case 1:
+		projectName, id = ".", args[0]
+	case 2:
+		projectName, id = args[0], args[1]
+	default:
+		return newUserError("need code review ID, and an optional project")
+	}
+
+	ws := reviewCmd.WorkSpace()
+	err := ws.LoadRemotes(reviewCmd.O.NoCache)
+	if err != nil {
+		return err
+	}
+	projects, err := ws.GetProjects(nil, projectName)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("cannot find project matched for '%s'", projectName)
+	}
+	querier, q, err := reviewCmd.reviewQuerier(projects[0])
+	if err != nil {
+		return err
+	}
+	review, err := querier.GetReview(q, id)
+	if err != nil {
+		return err
+	}
+
+	if reviewCmd.O.JSON {
+		data, err := json.MarshalIndent(review, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, item := range [][2]string{
+		{"Review", "#" + review.ID},
+		{"URL", review.URL},
+		{"Project", review.Project},
+		{"Branch", review.Branch},
+		{"Topic", review.Topic},
+		{"Subject", review.Subject},
+		{"Owner", review.Owner},
+		{"Status", review.Status},
+		{"Patch", review.Patch},
+		{"Ref", review.Ref},
+		{"Commit", review.Commit},
+		{"Updated", review.Updated},
+	} {
+		if item[1] != "" {
+			fmt.Printf("%-9s %s\n", item[0]+":", item[1])
+		}
+	}
+	return nil
+}
+
+var reviewShowCmd = reviewShowCommand{}
+
+func init() {
+	reviewCmd.Command().AddCommand(reviewShowCmd.Command())
+}
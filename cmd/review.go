@@ -0,0 +1,121 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
+	"github.com/alibaba/git-repo-go/project"
+	"github.com/spf13/cobra"
+)
+
+type reviewCommand struct {
+	WorkSpaceCommand
+
+	cmd *cobra.Command
+	O   struct {
+		JSON    bool
+		NoCache bool
+		Remote  string
+	}
+}
+
+func (v *reviewCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "review <subcommand>",
+		Short: "Query code reviews on review server",
+	}
+	v.cmd.PersistentFlags().BoolVar(&v.O.JSON,
+		"json",
+		false,
+		"output in JSON format")
+	v.cmd.PersistentFlags().BoolVar(&v.O.NoCache,
+		"no-cache",
+		false,
+		"Ignore ssh-info cache, and recheck ssh-info API")
+	v.cmd.PersistentFlags().StringVar(&v.O.Remote,
+		"remote",
+		"",
+		"use specific remote to query (use with --single)")
+
+	return v.cmd
+}
+
+// reviewRootURL returns root URL of review server to call its HTTP API.
+// For review URL using SSH protocol, HTTPS is used instead.
+func reviewRootURL(review string) string {
+	if strings.HasPrefix(review, "http://") || strings.HasPrefix(review, "https://") {
+		return strings.TrimSuffix(review, "/")
+	}
+	u := config.ParseGitURL(review)
+	if u == nil || u.Host == "" {
+		return review
+	}
+	return "https://" + u.Host
+}
+
+// reviewQuerier returns review querier and query conditions for project.
+func (v reviewCommand) reviewQuerier(p *project.Project) (helper.ReviewQuerier, *helper.ReviewQuery, error) {
+	return newReviewQuerier(p, v.O.Remote)
+}
+
+// newReviewQuerier returns review querier and query conditions for project
+// using remote of remoteName, or the default remote if remoteName is empty.
+func newReviewQuerier(p *project.Project, remoteName string) (helper.ReviewQuerier, *helper.ReviewQuery, error) {
+	var remote *project.Remote
+
+	if remoteName != "" {
+		remote = p.Remotes.Get(remoteName)
+	} else {
+		remote = p.GetDefaultRemote(true)
+	}
+	if remote == nil || !remote.ProtoHelperReady() {
+		return nil, nil, fmt.Errorf("%sno remote for code review", p.Prompt())
+	}
+	querier, err := helper.NewReviewQuerier(remote.ProtoHelper)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := p.Name
+	// Name of project in single mode is the path of repository in remote URL.
+	if config.IsSingleMode() {
+		u := config.ParseGitURL(p.GitConfigRemoteURL(remote.Name))
+		if u != nil && u.Repo != "" {
+			name = u.Repo
+		}
+	}
+	return querier, &helper.ReviewQuery{
+		URL:     reviewRootURL(remote.Review),
+		Project: name,
+	}, nil
+}
+
+var reviewCmd = reviewCommand{
+	WorkSpaceCommand: WorkSpaceCommand{
+		MirrorOK: false,
+		SingleOK: true,
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd.Command())
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewRootURL(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("https://review.example.com", reviewRootURL("https://review.example.com/"))
+	assert.Equal("http://example.com/gerrit", reviewRootURL("http://example.com/gerrit"))
+	assert.Equal("https://review.example.com", reviewRootURL("ssh://git@review.example.com:29418"))
+}
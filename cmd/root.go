@@ -135,11 +135,15 @@ this command with special options.`,
 	v.cmd.PersistentFlags().Int("mock-ssh-info-status",
 		0,
 		"mock remote ssh_info status")
+	v.cmd.PersistentFlags().String("mock-review-query-response",
+		"",
+		"mock response of code review query API")
 
 	v.cmd.PersistentFlags().MarkHidden("assume-yes")
 	v.cmd.PersistentFlags().MarkHidden("assume-no")
 	v.cmd.PersistentFlags().MarkHidden("mock-ssh-info-status")
 	v.cmd.PersistentFlags().MarkHidden("mock-ssh-info-response")
+	v.cmd.PersistentFlags().MarkHidden("mock-review-query-response")
 	v.cmd.PersistentFlags().MarkHidden("mock-no-symlink")
 	v.cmd.PersistentFlags().MarkHidden("mock-no-tty")
 
@@ -173,6 +177,9 @@ this command with special options.`,
 	viper.BindPFlag(
 		"mock-ssh-info-status",
 		v.cmd.PersistentFlags().Lookup("mock-ssh-info-status"))
+	viper.BindPFlag(
+		"mock-review-query-response",
+		v.cmd.PersistentFlags().Lookup("mock-review-query-response"))
 
 	return v.cmd
 }
@@ -0,0 +1,64 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/jiangxin/multi-log"
+	"github.com/spf13/cobra"
+)
+
+type sshInfoClearCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *sshInfoClearCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "clear [<url>...]",
+		Short: "Remove cached ssh_info, all of them if no URL is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v sshInfoClearCommand) Execute(args []string) error {
+	caches, err := sshInfoCmd.caches()
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, cache := range caches {
+		n, err := cache.Query.ClearCache(args...)
+		total += n
+		if err != nil {
+			return err
+		}
+	}
+	log.Notef("removed %d ssh_info cache entries", total)
+	return nil
+}
+
+var sshInfoClearCmd = sshInfoClearCommand{}
+
+func init() {
+	sshInfoCmd.Command().AddCommand(sshInfoClearCmd.Command())
+}
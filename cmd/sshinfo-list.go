@@ -0,0 +1,114 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alibaba/git-repo-go/helper"
+	log "github.com/jiangxin/multi-log"
+	"github.com/spf13/cobra"
+)
+
+type sshInfoListCommand struct {
+	cmd *cobra.Command
+}
+
+// sshInfoCacheFile holds entries of a ssh_info cache file.
+type sshInfoCacheFile struct {
+	File    string                     `json:"file"`
+	Entries []helper.SSHInfoCacheEntry `json:"entries"`
+}
+
+func (v *sshInfoListCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List cached ssh_info",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+// sshInfoSummary returns type of ssh_info, or "error" for negative cache.
+func sshInfoSummary(entry *helper.SSHInfoCacheEntry) string {
+	if entry.SSHInfo != nil {
+		return entry.SSHInfo.ProtoType
+	}
+	return "error"
+}
+
+// sshInfoExpire returns expire time of cache entry.
+func sshInfoExpire(entry *helper.SSHInfoCacheEntry) string {
+	if entry.Expired {
+		return entry.Expire + " (expired)"
+	}
+	return entry.Expire
+}
+
+func (v sshInfoListCommand) Execute(args []string) error {
+	if len(args) > 0 {
+		return newUserError("no args for list")
+	}
+	caches, err := sshInfoCmd.caches()
+	if err != nil {
+		return err
+	}
+
+	result := []sshInfoCacheFile{}
+	for _, cache := range caches {
+		entries := cache.Query.CacheEntries()
+		if len(entries) == 0 {
+			continue
+		}
+		result = append(result, sshInfoCacheFile{File: cache.File, Entries: entries})
+	}
+
+	if sshInfoCmd.O.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(result) == 0 {
+		log.Note("no ssh_info cache found")
+		return nil
+	}
+	for _, item := range result {
+		fmt.Printf("%s:\n", item.File)
+		for i := range item.Entries {
+			fmt.Printf("  %-35s %-8s %s\n",
+				item.Entries[i].Key,
+				sshInfoSummary(&item.Entries[i]),
+				sshInfoExpire(&item.Entries[i]))
+		}
+	}
+	return nil
+}
+
+var sshInfoListCmd = sshInfoListCommand{}
+
+func init() {
+	sshInfoCmd.Command().AddCommand(sshInfoListCmd.Command())
+}
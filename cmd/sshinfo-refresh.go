@@ -0,0 +1,95 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/jiangxin/multi-log"
+	"github.com/spf13/cobra"
+)
+
+type sshInfoRefreshCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *sshInfoRefreshCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "refresh [<url>...]",
+		Short: "Query ssh_info again and update the cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v sshInfoRefreshCommand) Execute(args []string) error {
+	caches, err := sshInfoCmd.caches()
+	if err != nil {
+		return err
+	}
+	if len(caches) == 0 {
+		return fmt.Errorf("no ssh_info cache file in workspace")
+	}
+
+	failed := 0
+	refresh := func(cache sshInfoCache, address string) {
+		sshInfo, err := cache.Query.RefreshCache(address)
+		if err != nil {
+			log.Errorf("fail to refresh ssh_info of '%s': %s", address, err)
+			failed++
+			return
+		}
+		fmt.Printf("%-35s %s\n", address, sshInfo.ToJSON())
+	}
+
+	if len(args) == 0 {
+		for _, cache := range caches {
+			for _, entry := range cache.Query.CacheEntries() {
+				refresh(cache, entry.Key)
+			}
+		}
+	}
+	for _, address := range args {
+		found := false
+		for _, cache := range caches {
+			if cache.Query.CacheEntry(address) != nil {
+				found = true
+				refresh(cache, address)
+			}
+		}
+		// Save new ssh_info in the cache file of workspace.
+		if !found {
+			refresh(caches[0], address)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("fail to refresh %d ssh_info", failed)
+	}
+	return nil
+}
+
+var sshInfoRefreshCmd = sshInfoRefreshCommand{}
+
+func init() {
+	sshInfoCmd.Command().AddCommand(sshInfoRefreshCmd.Command())
+}
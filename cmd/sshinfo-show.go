@@ -0,0 +1,96 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alibaba/git-repo-go/helper"
+	"github.com/spf13/cobra"
+)
+
+type sshInfoShowCommand struct {
+	cmd *cobra.Command
+}
+
+func (v *sshInfoShowCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "show <url>",
+		Short: "Show cached ssh_info of review URL",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return v.Execute(args)
+		},
+	}
+
+	return v.cmd
+}
+
+func (v sshInfoShowCommand) Execute(args []string) error {
+	if len(args) != 1 {
+		return newUserError("only one review URL should be given")
+	}
+	caches, err := sshInfoCmd.caches()
+	if err != nil {
+		return err
+	}
+
+	result := []sshInfoCacheFile{}
+	for _, cache := range caches {
+		entry := cache.Query.CacheEntry(args[0])
+		if entry == nil {
+			continue
+		}
+		result = append(result, sshInfoCacheFile{
+			File:    cache.File,
+			Entries: []helper.SSHInfoCacheEntry{*entry},
+		})
+	}
+	if len(result) == 0 {
+		return fmt.Errorf("no ssh_info cache for '%s'", args[0])
+	}
+
+	if sshInfoCmd.O.JSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, item := range result {
+		entry := item.Entries[0]
+		fmt.Printf("%s:\n", item.File)
+		fmt.Printf("  %-8s %s\n", "key:", entry.Key)
+		if entry.SSHInfo != nil {
+			fmt.Printf("  %-8s %s\n", "sshinfo:", entry.SSHInfo.ToJSON())
+		}
+		if entry.Error != "" {
+			fmt.Printf("  %-8s %s\n", "error:", entry.Error)
+		}
+		fmt.Printf("  %-8s %s\n", "expire:", sshInfoExpire(&entry))
+	}
+	return nil
+}
+
+var sshInfoShowCmd = sshInfoShowCommand{}
+
+func init() {
+	sshInfoCmd.Command().AddCommand(sshInfoShowCmd.Command())
+}
@@ -0,0 +1,110 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
+	"github.com/spf13/cobra"
+)
+
+type sshInfoCommand struct {
+	WorkSpaceCommand
+
+	cmd *cobra.Command
+	O   struct {
+		JSON bool
+	}
+}
+
+// sshInfoCache wraps ssh_info query of a cache file.
+type sshInfoCache struct {
+	File  string
+	Query *helper.SSHInfoQuery
+}
+
+func (v *sshInfoCommand) Command() *cobra.Command {
+	if v.cmd != nil {
+		return v.cmd
+	}
+
+	v.cmd = &cobra.Command{
+		Use:   "sshinfo <subcommand>",
+		Short: "Manage cache of ssh_info of review servers",
+		Long: `Inspect and manage ssh_info cached in the workspace.
+
+The cache expires in 12 hours, which can be changed by git config variable
+"repo.sshInfoTTL" (seconds, or duration such as "30m"). Failures of ssh_info
+query are not cached unless "repo.sshInfoNegativeTTL" is set.`,
+	}
+	v.cmd.PersistentFlags().BoolVar(&v.O.JSON,
+		"json",
+		false,
+		"output in JSON format")
+
+	return v.cmd
+}
+
+// caches returns ssh_info caches of the workspace and its projects. The
+// first one is where new ssh_info is saved.
+func (v *sshInfoCommand) caches() ([]sshInfoCache, error) {
+	var (
+		files  []string
+		caches []sshInfoCache
+		found  = make(map[string]bool)
+	)
+
+	if !config.IsSingleMode() {
+		files = append(files, v.RepoWorkSpace().ManifestProject.SSHInfoCacheFile())
+	}
+	projects, err := v.WorkSpace().GetProjects(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		files = append(files, p.SSHInfoCacheFile())
+	}
+
+	cwd, _ := os.Getwd()
+	for _, file := range files {
+		if found[file] {
+			continue
+		}
+		found[file] = true
+		cache := sshInfoCache{
+			File:  file,
+			Query: helper.NewSSHInfoQuery(file),
+		}
+		if rel, err := filepath.Rel(cwd, file); err == nil && cwd != "" {
+			cache.File = rel
+		}
+		caches = append(caches, cache)
+	}
+	return caches, nil
+}
+
+var sshInfoCmd = sshInfoCommand{
+	WorkSpaceCommand: WorkSpaceCommand{
+		MirrorOK: true,
+		SingleOK: true,
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshInfoCmd.Command())
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/alibaba/git-repo-go/cap"
 	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
 	"github.com/alibaba/git-repo-go/project"
 	"github.com/alibaba/git-repo-go/workspace"
 	log "github.com/jiangxin/multi-log"
@@ -60,6 +61,8 @@ type syncCommand struct {
 		Prune                  bool
 		SmartSync              bool
 		SmartTag               string
+		UseSuperproject        bool
+		FetchReviews           string
 	}
 }
 
@@ -163,6 +166,15 @@ func (v *syncCommand) Command() *cobra.Command {
 		"t",
 		"",
 		"smart sync using manifest from a known tag")
+	v.cmd.Flags().BoolVar(&v.O.UseSuperproject,
+		"use-superproject",
+		false,
+		"use the superproject defined in manifest to resolve revisions of projects")
+	v.cmd.Flags().StringVar(&v.O.FetchReviews,
+		"fetch-reviews",
+		"",
+		"fetch open code reviews (mine, all, or a query) into refs/reviews/ for offline review")
+	v.cmd.Flags().Lookup("fetch-reviews").NoOptDefVal = "mine"
 
 	return v.cmd
 }
@@ -279,6 +291,25 @@ func (v *syncCommand) updateManifestProject() error {
 	return nil
 }
 
+// updateRevisionsFromSuperproject pins revisions of projects to commits
+// recorded in superproject. Fall back to revisions in manifest on failure.
+func (v syncCommand) updateRevisionsFromSuperproject(allProjects []*project.Project) {
+	var fetchOptions *project.FetchOptions
+
+	rws := v.RepoWorkSpace()
+	if rws.ManifestProject.MirrorEnabled() {
+		log.Warnf("superproject is ignored in mirror mode")
+		return
+	}
+	if !v.O.LocalOnly {
+		fetchOptions = &v.FetchOptions
+	}
+	err := rws.UpdateRevisionsFromSuperproject(allProjects, fetchOptions)
+	if err != nil {
+		log.Warnf("fail to use superproject, fall back to revisions in manifest: %s", err)
+	}
+}
+
 func (v syncCommand) NetworkHalf(allProjects []*project.Project) error {
 	var (
 		err  error
@@ -420,6 +451,64 @@ func (v syncCommand) LocalHalf(allProjects []*project.Project) error {
 	return errors.New(errMsg)
 }
 
+// fetchReviews fetches open code reviews of projects into local references
+// "refs/reviews/<id>/<patch>", so that they can be downloaded offline.
+func (v syncCommand) fetchReviews(allProjects []*project.Project) {
+	for _, p := range allProjects {
+		if !p.IsRepoInitialized() {
+			continue
+		}
+		querier, q, err := newReviewQuerier(p, "")
+		if err != nil {
+			log.Debugf("%scannot fetch code reviews: %s", p.Prompt(), err)
+			continue
+		}
+		switch v.O.FetchReviews {
+		case "mine":
+			m := helper.UserEmailPattern.FindStringSubmatch(p.UserEmail())
+			if m == nil {
+				log.Warnf("%scannot find email of current user to fetch code reviews", p.Prompt())
+				continue
+			}
+			q.Owner = m[2] + "@" + m[3]
+		case "all":
+		default:
+			q.Query = v.O.FetchReviews
+		}
+		q.Status = helper.ReviewStatusOpen
+		reviews, err := querier.ListReviews(q)
+		if err != nil {
+			log.Warnf("%sfail to query code reviews: %s", p.Prompt(), err)
+			continue
+		}
+		fetched, err := p.FetchReviews(p.GetDefaultRemote(true), reviews)
+		if err != nil {
+			log.Warnf("%s%s", p.Prompt(), err)
+		}
+		// Code reviews fetched before but not found by this query, are
+		// pruned only if they are closed.
+		pruned, err := p.PruneReviews(reviews, func(id string) bool {
+			if v.O.FetchReviews == "all" {
+				return true
+			}
+			review, err := querier.GetReview(q, id)
+			if err != nil {
+				log.Debugf("%sfail to check code review #%s: %s", p.Prompt(), id, err)
+				return false
+			}
+			return review.Status == helper.ReviewStatusMerged ||
+				review.Status == helper.ReviewStatusClosed
+		})
+		if err != nil {
+			log.Warnf("%s%s", p.Prompt(), err)
+		}
+		if fetched > 0 || pruned > 0 {
+			log.Notef("%sfetched %d code review(s), pruned %d code review(s)",
+				p.Prompt(), fetched, pruned)
+		}
+	}
+}
+
 func (v syncCommand) Execute(args []string) error {
 	var (
 		err error
@@ -497,6 +586,10 @@ func (v syncCommand) Execute(args []string) error {
 		SubmodulesOK: v.O.FetchSubmodules,
 	}, args...)
 
+	if v.O.UseSuperproject {
+		v.updateRevisionsFromSuperproject(allProjects)
+	}
+
 	if !v.O.LocalOnly {
 		err = v.NetworkHalf(allProjects)
 		if err != nil {
@@ -504,12 +597,22 @@ func (v syncCommand) Execute(args []string) error {
 		}
 	}
 
-	if v.O.NetworkOnly ||
-		rws.ManifestProject.MirrorEnabled() ||
+	if rws.ManifestProject.MirrorEnabled() ||
 		rws.ManifestProject.ArchiveEnabled() {
 		return nil
 	}
 
+	if v.O.NetworkOnly {
+		if v.O.FetchReviews != "" {
+			err = rws.LoadRemotes(v.O.NoCache)
+			if err != nil {
+				log.Error(err)
+			}
+			v.fetchReviews(allProjects)
+		}
+		return nil
+	}
+
 	// Call ssh_info API to detect types of remote servers
 	err = rws.LoadRemotes(v.O.NoCache)
 	if err != nil {
@@ -528,6 +631,10 @@ func (v syncCommand) Execute(args []string) error {
 		return err
 	}
 
+	if v.O.FetchReviews != "" && !v.O.LocalOnly {
+		v.fetchReviews(allProjects)
+	}
+
 	// If there's a notice that's supposed to print at the end of the sync,
 	// print it now...
 	if rws.Manifest != nil && rws.Manifest.Notice != "" {
@@ -57,6 +57,7 @@ func (v testSSHInfoCommand) Execute(args []string) error {
 		return err
 	}
 	fmt.Printf("ssh_info: %#v\n", sshInfo)
+	fmt.Printf("source: %s\n", helper.SSHInfoSource(args[0]))
 
 	return nil
 }
@@ -219,7 +219,7 @@ func (v upgradeCommand) GetUpgradeInfo() (*upgradeInfo, error) {
 	}
 
 	log.Debugf("checking upgrade version from %s", infoURL)
-	resp, err := v.HTTPClient().Do(req)
+	resp, err := helper.HTTPDo(v.HTTPClient(), req)
 	if err != nil {
 		return nil, err
 	}
@@ -256,7 +256,7 @@ func (v upgradeCommand) Download(URL string, dir string, showProgress bool) (str
 		return "", err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := helper.HTTPDo(client, req)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,368 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/project"
+)
+
+const pickerHelp = `Commands:
+  <n>...           toggle selection of branches, e.g.: "1 3-5"
+  a, n             select all or none of branches
+  l <n>            list commits of branch
+  t <n> <title>    set title of code review
+  r <n> <list>     set reviewers, separated by comma
+  d <n>            toggle draft mode
+  u                upload selected branches
+  q                quit without upload
+  ?                show this help`
+
+// uploadBranchOptions are options of code review for a branch, which are
+// set in the interactive picker, and override the global upload options.
+type uploadBranchOptions struct {
+	Title     string
+	Reviewers []string
+	Draft     bool
+}
+
+// uploadPickerItem is a branch to select in the interactive picker.
+type uploadPickerItem struct {
+	Path     string
+	Branch   string
+	Target   string   // Remote branch or code review to update.
+	Commits  []string // Commit list in one line format.
+	Notes    []string // Problems found by pre-upload checks.
+	Selected bool
+	Options  uploadBranchOptions
+}
+
+// uploadPicker is a line-oriented interactive picker, like the interactive
+// mode of git-add, to select branches for upload.
+type uploadPicker struct {
+	in    *bufio.Reader
+	out   io.Writer
+	items []*uploadPickerItem
+}
+
+func newUploadPicker(in io.Reader, out io.Writer, items []*uploadPickerItem) *uploadPicker {
+	return &uploadPicker{
+		in:    bufio.NewReader(in),
+		out:   out,
+		items: items,
+	}
+}
+
+// show prints branches and their options.
+func (v uploadPicker) show() {
+	fmt.Fprintln(v.out, "Branches ready for upload:")
+	for i, item := range v.items {
+		mark := " "
+		if item.Selected {
+			mark = "*"
+		}
+		fmt.Fprintf(v.out, "%3d: [%s] %s/ %s (%d commit(s)) %s\n",
+			i+1,
+			mark,
+			item.Path,
+			item.Branch,
+			len(item.Commits),
+			item.Target)
+		opts := []string{}
+		if item.Options.Title != "" {
+			opts = append(opts, "title: "+item.Options.Title)
+		}
+		if len(item.Options.Reviewers) > 0 {
+			opts = append(opts, "reviewers: "+strings.Join(item.Options.Reviewers, ", "))
+		}
+		if item.Options.Draft {
+			opts = append(opts, "draft")
+		}
+		if len(opts) > 0 {
+			fmt.Fprintf(v.out, "          %s\n", strings.Join(opts, "; "))
+		}
+		for _, note := range item.Notes {
+			fmt.Fprintf(v.out, "          %s\n", note)
+		}
+	}
+}
+
+// parseIndexes parses index list, such as "1 3-5", and returns zero-based
+// indexes of items.
+func (v uploadPicker) parseIndexes(fields []string) ([]int, error) {
+	indexes := []int{}
+	for _, field := range fields {
+		from, to := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			from, to = field[:i], field[i+1:]
+		}
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("bad index '%s'", field)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("bad index '%s'", field)
+		}
+		if start < 1 || end > len(v.items) || start > end {
+			return nil, fmt.Errorf("index '%s' out of range (1-%d)", field, len(v.items))
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	return indexes, nil
+}
+
+// item returns item by index given in command.
+func (v uploadPicker) item(arg string) (*uploadPickerItem, error) {
+	indexes, err := v.parseIndexes([]string{arg})
+	if err != nil {
+		return nil, err
+	}
+	if len(indexes) != 1 {
+		return nil, fmt.Errorf("need one index, got '%s'", arg)
+	}
+	return v.items[indexes[0]], nil
+}
+
+// Run reads commands from input until user confirms to upload or quits, and
+// returns true if user confirms to upload selected branches.
+func (v *uploadPicker) Run() bool {
+	v.show()
+	fmt.Fprintln(v.out, pickerHelp)
+	for {
+		fmt.Fprint(v.out, "What now> ")
+		line, err := v.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(v.out)
+			return false
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if err = v.execute(line); err == errPickerQuit {
+			return false
+		} else if err == errPickerDone {
+			return true
+		} else if err != nil {
+			fmt.Fprintf(v.out, "error: %s\n", err)
+		}
+	}
+}
+
+var (
+	errPickerDone = errors.New("done")
+	errPickerQuit = errors.New("quit")
+)
+
+// execute runs one command of the picker.
+func (v *uploadPicker) execute(line string) error {
+	fields := strings.Fields(line)
+	cmd, args := fields[0], fields[1:]
+	// Text after the index, used as title or reviewers.
+	rest := ""
+	if len(args) > 0 {
+		rest = strings.TrimSpace(line[len(cmd):])
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, args[0]))
+	}
+
+	switch cmd {
+	case "a", "n":
+		for _, item := range v.items {
+			item.Selected = cmd == "a"
+		}
+		v.show()
+	case "l":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: l <n>")
+		}
+		item, err := v.item(args[0])
+		if err != nil {
+			return err
+		}
+		for _, commit := range item.Commits {
+			fmt.Fprintf(v.out, "  %s\n", commit)
+		}
+	case "t":
+		if len(args) < 1 {
+			return fmt.Errorf("usage: t <n> <title>")
+		}
+		item, err := v.item(args[0])
+		if err != nil {
+			return err
+		}
+		item.Options.Title = rest
+		v.show()
+	case "r":
+		if len(args) < 1 {
+			return fmt.Errorf("usage: r <n> <reviewers>")
+		}
+		item, err := v.item(args[0])
+		if err != nil {
+			return err
+		}
+		item.Options.Reviewers = []string{}
+		for _, reviewer := range strings.Split(rest, ",") {
+			reviewer = strings.TrimSpace(reviewer)
+			if reviewer != "" {
+				item.Options.Reviewers = append(item.Options.Reviewers, reviewer)
+			}
+		}
+		v.show()
+	case "d":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: d <n>")
+		}
+		item, err := v.item(args[0])
+		if err != nil {
+			return err
+		}
+		item.Options.Draft = !item.Options.Draft
+		v.show()
+	case "u":
+		for _, item := range v.items {
+			if item.Selected {
+				return errPickerDone
+			}
+		}
+		return fmt.Errorf("no branch selected")
+	case "q":
+		return errPickerQuit
+	case "?", "h":
+		fmt.Fprintln(v.out, pickerHelp)
+	default:
+		if cmd[0] < '0' || cmd[0] > '9' {
+			return fmt.Errorf("unknown command '%s', input '?' for help", cmd)
+		}
+		indexes, err := v.parseIndexes(fields)
+		if err != nil {
+			return err
+		}
+		for _, i := range indexes {
+			v.items[i].Selected = !v.items[i].Selected
+		}
+		v.show()
+	}
+	return nil
+}
+
+// uploadBranchKey is the key to cache data of branch.
+func uploadBranchKey(branch *project.ReviewableBranch) string {
+	return branch.Project.Name + ":" + branch.Branch.Name
+}
+
+// UploadForReviewWithPicker selects branches and edits options of code
+// review in the interactive picker, and uploads selected branches.
+func (v uploadCommand) UploadForReviewWithPicker(branchesMap map[string][]project.ReviewableBranch) error {
+	var (
+		branches []project.ReviewableBranch
+		items    []*uploadPickerItem
+		in       io.Reader = os.Stdin
+	)
+
+	keys := []string{}
+	for key := range branchesMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		branches = append(branches, branchesMap[key]...)
+	}
+
+	reviewers := []string{}
+	for _, reviewer := range strings.Split(strings.Join(v.O.Reviewers, ","), ",") {
+		reviewer = strings.TrimSpace(reviewer)
+		if reviewer != "" {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	for i := range branches {
+		branch := &branches[i]
+		item := uploadPickerItem{
+			Path:     branch.Project.Path,
+			Branch:   branch.Branch.Name,
+			Selected: len(branches) == 1,
+			Options: uploadBranchOptions{
+				Title:     v.O.Title,
+				Reviewers: append([]string{}, reviewers...),
+				Draft:     v.O.Draft,
+			},
+		}
+		if codeReview := v.reviewToUpdate(branch); codeReview.Empty() {
+			destBranch, err := v.getDestBranch(branch)
+			if err != nil {
+				return err
+			}
+			item.Target = "to remote branch " + destBranch
+			if backports := v.backports(); len(backports) > 0 {
+				item.Target += ", backport to " + strings.Join(backports, ", ")
+			}
+		} else {
+			item.Target = "to update code review #" + codeReview.ID
+		}
+		for _, commit := range branch.Commits() {
+			result := branch.Project.ExecuteCommand(project.GIT, "show", "-s", "--format=%h %s", commit)
+			if result.Success() {
+				item.Commits = append(item.Commits, strings.TrimSpace(result.Stdout()))
+			} else {
+				item.Commits = append(item.Commits, commit)
+			}
+		}
+		for _, r := range v.lintBranch(branch) {
+			item.Notes = append(item.Notes, r.String())
+		}
+		if !v.O.NoAutoReviewer {
+			item.Options.Reviewers = appendUniqueStrings(item.Options.Reviewers,
+				branch.SuggestReviewers()...)
+		}
+		items = append(items, &item)
+	}
+
+	if v.O.MockPickerInput != "" {
+		f, err := os.Open(v.O.MockPickerInput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if !newUploadPicker(in, os.Stdout, items).Run() {
+		return fmt.Errorf("upload aborted by user")
+	}
+
+	todo := []project.ReviewableBranch{}
+	for i, item := range items {
+		if !item.Selected {
+			continue
+		}
+		options := item.Options
+		v.branchOptions[uploadBranchKey(&branches[i])] = &options
+		todo = append(todo, branches[i])
+	}
+	// Suggested reviewers are shown in the picker, and user may remove some.
+	v.O.NoAutoReviewer = true
+	return v.UploadAndReport(todo)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPickerItems() []*uploadPickerItem {
+	return []*uploadPickerItem{
+		{
+			Path:    "main",
+			Branch:  "topic",
+			Target:  "to remote branch master",
+			Commits: []string{"1234567 commit 1"},
+			Options: uploadBranchOptions{Title: "title"},
+		},
+		{
+			Path:    "app",
+			Branch:  "topic",
+			Target:  "to update code review #12",
+			Commits: []string{"2345678 commit 2", "3456789 commit 3"},
+		},
+		{
+			Path:    "lib",
+			Branch:  "fix",
+			Target:  "to remote branch master",
+			Commits: []string{"4567890 commit 4"},
+		},
+	}
+}
+
+func TestUploadPickerSelect(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		out    bytes.Buffer
+	)
+
+	items := testPickerItems()
+	picker := newUploadPicker(strings.NewReader("1-2\n2 3\nu\n"), &out, items)
+	assert.True(picker.Run())
+	assert.True(items[0].Selected)
+	assert.False(items[1].Selected)
+	assert.True(items[2].Selected)
+
+	items = testPickerItems()
+	picker = newUploadPicker(strings.NewReader("a\nn\n"), &out, items)
+	assert.False(picker.Run())
+	for _, item := range items {
+		assert.False(item.Selected)
+	}
+
+	items = testPickerItems()
+	picker = newUploadPicker(strings.NewReader("1\nq\nu\n"), &out, items)
+	assert.False(picker.Run())
+}
+
+func TestUploadPickerOptions(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		out    bytes.Buffer
+	)
+
+	items := testPickerItems()
+	input := `t 2   New title  of review
+r 2 user1,  user2 ,
+d 2
+t 1
+u
+a
+u
+`
+	picker := newUploadPicker(strings.NewReader(input), &out, items)
+	assert.True(picker.Run())
+	assert.Equal("", items[0].Options.Title)
+	assert.Equal("New title  of review", items[1].Options.Title)
+	assert.Equal([]string{"user1", "user2"}, items[1].Options.Reviewers)
+	assert.True(items[1].Options.Draft)
+	assert.False(items[2].Options.Draft)
+	assert.Contains(out.String(), "error: no branch selected\n")
+}
+
+func TestUploadPickerErrors(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		out    bytes.Buffer
+	)
+
+	items := testPickerItems()
+	input := `4
+x
+l
+l 2
+r 0 user
+`
+	picker := newUploadPicker(strings.NewReader(input), &out, items)
+	assert.False(picker.Run())
+	assert.Contains(out.String(), "error: index '4' out of range (1-3)\n")
+	assert.Contains(out.String(), "error: unknown command 'x', input '?' for help\n")
+	assert.Contains(out.String(), "error: usage: l <n>\n")
+	assert.Contains(out.String(), "What now>   2345678 commit 2\n  3456789 commit 3\n")
+	assert.Contains(out.String(), "error: index '0' out of range (1-3)\n")
+	for _, item := range items {
+		assert.False(item.Selected)
+		assert.Empty(item.Options.Reviewers)
+	}
+}
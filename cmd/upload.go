@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -23,6 +25,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/alibaba/git-repo-go/cap"
 	"github.com/alibaba/git-repo-go/common"
 	"github.com/alibaba/git-repo-go/config"
 	"github.com/alibaba/git-repo-go/editor"
@@ -41,6 +44,9 @@ const (
 	// uploadOptionsFile stores upload options to file
 	uploadOptionsFile = "UPLOAD_OPTIONS"
 	uploadOptionsDir  = "UPLOAD_OPTIONS.d"
+
+	// uploadTopicsDir stores status of failed atomic uploads for resume
+	uploadTopicsDir = "UPLOAD_TOPICS.d"
 )
 
 var (
@@ -48,30 +54,48 @@ var (
 )
 
 type uploadOptions struct {
-	AllowAllHooks  bool
-	AutoTopic      bool
-	Branch         string
-	BypassHooks    bool
-	Cc             []string
-	CodeReview     config.CodeReview
-	CurrentBranch  bool
-	Description    string
-	DestBranch     string
-	Draft          bool
-	Issue          string
-	MockGitPush    bool
-	MockEditScript string
-	NoCache        bool
-	NoCertChecks   bool
-	NoEdit         bool
-	NoEmails       bool
-	Private        bool
-	PushOptions    []string
-	ReRun          bool
-	Reviewers      []string
-	Remote         string
-	Title          string
-	WIP            bool
+	AllowAllHooks   bool
+	Atomic          bool
+	AutoTopic       bool
+	Branch          string
+	BypassHooks     bool
+	Cc              []string
+	CodeReview      config.CodeReview
+	CurrentBranch   bool
+	Description     string
+	DestBranch      string
+	DestBranches    []string
+	Draft           bool
+	Hashtags        []string
+	Issue           string
+	Labels          []string
+	Message         string
+	MockGitPush     bool
+	MockEditScript  string
+	MockPickerInput string
+	MockPushOutput  string
+	NewReview       bool
+	NoAutoReviewer  bool
+	NoCache         bool
+	NoCertChecks    bool
+	NoEdit          bool
+	NoEmails        bool
+	NoPicker        bool
+	Picker          bool
+	Private         bool
+	PublishComment  bool
+	PushOptions     []string
+	ReRun           bool
+	Rebase          bool
+	RemoveSource    bool
+	ReportJSON      string
+	Resume          bool
+	Reviewers       []string
+	Remote          string
+	Stacked         bool
+	Title           string
+	Topic           string
+	WIP             bool
 }
 
 // LoadFromFile reads content from file and parses into push options.
@@ -108,19 +132,31 @@ func (v *uploadOptions) LoadFromText(data string) {
 			v.Reviewers = strings.Split(text, "\n")
 		case "cc":
 			v.Cc = strings.Split(text, "\n")
-		case "draft", "private":
+		case "topic":
+			v.Topic = strings.Split(text, "\n")[0]
+		case "hashtag":
+			v.Hashtags = strings.Split(text, "\n")
+		case "label":
+			v.Labels = strings.Split(text, "\n")
+		case "message":
+			v.Message = text
+		case "draft", "private", "publish-comments":
 			switch text {
 			case "y", "yes", "on", "t", "true", "1":
 				if section == "draft" {
 					v.Draft = true
 				} else if section == "private" {
 					v.Private = true
+				} else if section == "publish-comments" {
+					v.PublishComment = true
 				}
 			case "n", "no", "off", "f", "false", "0":
 				if section == "draft" {
 					v.Draft = false
 				} else if section == "private" {
 					v.Private = false
+				} else if section == "publish-comments" {
+					v.PublishComment = false
 				}
 			default:
 				log.Warnf("cannot turn '%s' to boolean", text)
@@ -142,7 +178,12 @@ func (v *uploadOptions) LoadFromText(data string) {
 				"reviewer",
 				"cc",
 				"draft",
-				"private":
+				"private",
+				"topic",
+				"hashtag",
+				"label",
+				"message",
+				"publish-comments":
 
 				if section != "" {
 					setUploadOption(section, text)
@@ -169,8 +210,9 @@ func (v *uploadOptions) LoadFromText(data string) {
 	}
 }
 
-// Export will export uploadOptions for edit.
-func (v *uploadOptions) Export(published bool) []string {
+// Export will export uploadOptions for edit. Options only for Gerrit
+// are exported if gerrit is true or they are not empty.
+func (v *uploadOptions) Export(published, gerrit bool) []string {
 	script := []string{}
 	w := 13
 	if !published {
@@ -241,6 +283,64 @@ func (v *uploadOptions) Export(published bool) []string {
 	}
 	script = append(script, "")
 
+	if gerrit || v.Topic != "" {
+		script = append(script, fmt.Sprintf("# %-*s : %s", w,
+			"[Topic]",
+			"one line message below as the topic of code review"),
+		)
+		if v.Topic != "" {
+			script = append(script, "", v.Topic)
+		}
+		script = append(script, "")
+	}
+
+	if gerrit || len(v.Hashtags) > 0 {
+		script = append(script, fmt.Sprintf("# %-*s : %s", w,
+			"[Hashtag]",
+			"multiple lines of hashtags for code review"),
+		)
+		if len(v.Hashtags) > 0 {
+			script = append(script, "")
+			script = append(script, v.Hashtags...)
+		}
+		script = append(script, "")
+	}
+
+	if gerrit || len(v.Labels) > 0 {
+		script = append(script, fmt.Sprintf("# %-*s : %s", w,
+			"[Label]",
+			"multiple lines of labels to vote, such as Code-Review+1"),
+		)
+		if len(v.Labels) > 0 {
+			script = append(script, "")
+			script = append(script, v.Labels...)
+		}
+		script = append(script, "")
+	}
+
+	if gerrit || v.Message != "" {
+		script = append(script, fmt.Sprintf("# %-*s : %s", w,
+			"[Message]",
+			"multiple lines of text as the message for this patch set"),
+		)
+		if v.Message != "" {
+			script = append(script, "")
+			script = append(script, strings.Split(v.Message, "\n")...)
+		}
+		script = append(script, "")
+	}
+
+	if gerrit || v.PublishComment {
+		script = append(script, fmt.Sprintf("# %-*s : %s", w,
+			"[Publish-Comments]",
+			"a boolean (yes/no, or true/false) to publish draft comments"),
+		)
+		if v.PublishComment {
+			script = append(script, "", "yes")
+		}
+		script = append(script, "")
+	}
+
 	return script
 }
 
@@ -249,6 +349,20 @@ type uploadCommand struct {
 
 	cmd *cobra.Command
 	O   uploadOptions
+
+	// lintResults caches results of pre-upload checks of branches.
+	lintResults map[string][]project.LintResult
+
+	// branchOptions are options for each branch set in the picker.
+	branchOptions map[string]*uploadBranchOptions
+
+	// branchReviewers are reviewers from owners for each branch, which
+	// may be edited in the editor script.
+	branchReviewers map[string][]string
+
+	// savedReviews are code reviews uploaded from each branch before,
+	// which will be updated by this upload.
+	savedReviews map[string]config.CodeReview
 }
 
 func (v *uploadCommand) Command() *cobra.Command {
@@ -272,7 +386,7 @@ func (v *uploadCommand) Command() *cobra.Command {
 			name = "reviewers"
 		case "current-branch":
 			name = "cbr"
-		case "destination":
+		case "destination", "dest-branch":
 			name = "dest"
 		case "rerun":
 			name = "re-run"
@@ -294,6 +408,10 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"cc",
 		nil,
 		"Also send email to these email addresses")
+	v.cmd.Flags().BoolVar(&v.O.NoAutoReviewer,
+		"no-auto-reviewers",
+		false,
+		"Do not suggest reviewers from OWNERS or CODEOWNERS files")
 	v.cmd.Flags().StringVar(&v.O.Branch,
 		"br",
 		"",
@@ -303,6 +421,10 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"c",
 		"",
 		"ID of the specific code review to change")
+	v.cmd.Flags().BoolVar(&v.O.NewReview,
+		"new-review",
+		false,
+		"Create new code review, instead of updating the one uploaded from the branch before")
 	v.cmd.Flags().BoolVar(&v.O.CurrentBranch,
 		"cbr",
 		false,
@@ -338,20 +460,56 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"w",
 		false,
 		"If specified, upload as a work-in-progress change")
+	v.cmd.Flags().StringVar(&v.O.Topic,
+		"topic",
+		"",
+		"Topic for review")
+	v.cmd.Flags().BoolVar(&v.O.Atomic,
+		"atomic",
+		false,
+		"Validate all branches before upload, and stop on first failure (use with --topic)")
+	v.cmd.Flags().BoolVar(&v.O.Resume,
+		"resume",
+		false,
+		"Retry branches failed in last atomic upload of the topic (use with --topic)")
+	v.cmd.Flags().BoolVar(&v.O.Stacked,
+		"stacked",
+		false,
+		"Upload each commit as its own review, which depends on the review of its parent (AGit)")
+	v.cmd.Flags().StringArrayVar(&v.O.Hashtags,
+		"hashtag",
+		nil,
+		"Add hashtags for review")
+	v.cmd.Flags().StringArrayVar(&v.O.Labels,
+		"label",
+		nil,
+		"Labels to vote for review, such as Code-Review+1 (Gerrit only)")
+	v.cmd.Flags().StringVar(&v.O.Message,
+		"message",
+		"",
+		"Message for the uploaded patch set (Gerrit only)")
+	v.cmd.Flags().BoolVar(&v.O.PublishComment,
+		"publish-comments",
+		false,
+		"Publish draft comments on upload (Gerrit only)")
 	v.cmd.Flags().StringArrayVarP(&v.O.PushOptions,
 		"push-options",
 		"o",
 		nil,
 		"Additional push options to transmit")
+	v.cmd.Flags().BoolVar(&v.O.RemoveSource,
+		"remove-source-branch",
+		false,
+		"Remove source branch when merge request is merged (GitLab only)")
 	v.cmd.Flags().StringVar(&v.O.Remote,
 		"remote",
 		"",
 		"use specific remote for upload (use with --single)")
-	v.cmd.Flags().StringVarP(&v.O.DestBranch,
+	v.cmd.Flags().StringArrayVarP(&v.O.DestBranches,
 		"dest",
 		"D",
-		"",
-		"Submit for review on this target branch")
+		nil,
+		"Submit for review on this target branch, and backport to more branches if given multiple times")
 	v.cmd.Flags().BoolVar(&v.O.NoCertChecks,
 		"no-cert-checks",
 		false,
@@ -360,14 +518,22 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"re-run",
 		false,
 		"Ignore checking for publish of branches to re-run the upload")
+	v.cmd.Flags().BoolVar(&v.O.Rebase,
+		"rebase",
+		false,
+		"Fetch and rebase onto the latest destination branch before upload")
 	v.cmd.Flags().BoolVar(&v.O.BypassHooks,
 		"no-verify",
 		false,
-		"Do not run the upload hook")
+		"Do not run the upload hook, and upload even if pre-upload checks fail")
 	v.cmd.Flags().BoolVar(&v.O.AllowAllHooks,
 		"verify",
 		false,
 		"Run the upload hook without prompting")
+	v.cmd.Flags().StringVar(&v.O.ReportJSON,
+		"report-json",
+		"",
+		"Write upload report in JSON to file (\"-\" for stdout)")
 	v.cmd.Flags().BoolVar(&v.O.NoCache,
 		"no-cache",
 		false,
@@ -377,6 +543,14 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"no-edit",
 		false,
 		"If specified, do not open editor to confirm")
+	v.cmd.Flags().BoolVar(&v.O.Picker,
+		"picker",
+		false,
+		"Use interactive picker instead of editor to select branches")
+	v.cmd.Flags().BoolVar(&v.O.NoPicker,
+		"no-picker",
+		false,
+		"Use editor to select branches, even if upload.picker is true")
 	v.cmd.Flags().BoolVar(&v.O.MockGitPush,
 		"mock-git-push",
 		false,
@@ -385,10 +559,20 @@ func (v *uploadCommand) Command() *cobra.Command {
 		"mock-edit-script",
 		"",
 		"Mock edit script result file")
+	v.cmd.Flags().StringVar(&v.O.MockPickerInput,
+		"mock-picker-input",
+		"",
+		"Mock input file of interactive picker")
+	v.cmd.Flags().StringVar(&v.O.MockPushOutput,
+		"mock-push-output",
+		"",
+		"Mock git-push output file")
 
 	v.cmd.Flags().MarkHidden("auto-topic")
 	v.cmd.Flags().MarkHidden("mock-git-push")
 	v.cmd.Flags().MarkHidden("mock-edit-script")
+	v.cmd.Flags().MarkHidden("mock-picker-input")
+	v.cmd.Flags().MarkHidden("mock-push-output")
 
 	return v.cmd
 }
@@ -415,6 +599,20 @@ func (v *uploadCommand) getDestBranch(branch *project.ReviewableBranch) (string,
 	return destBranch, nil
 }
 
+// backports returns extra destination branches to backport to, which are
+// given by multiple --dest options.
+func (v uploadCommand) backports() []string {
+	result := []string{}
+	for i, dest := range v.O.DestBranches {
+		dest = strings.TrimPrefix(dest, config.RefsHeads)
+		if i == 0 || dest == strings.TrimPrefix(v.O.DestBranch, config.RefsHeads) {
+			continue
+		}
+		result = appendUniqueStrings(result, dest)
+	}
+	return result
+}
+
 func (v uploadCommand) UploadForReviewWithConfirm(branchesMap map[string][]project.ReviewableBranch) error {
 	var (
 		answer   bool
@@ -468,7 +666,7 @@ func (v uploadCommand) UploadForReviewWithConfirm(branchesMap map[string][]proje
 				draftStr = " (draft)"
 			}
 
-			if branch.CodeReview.Empty() {
+			if codeReview := v.reviewToUpdate(&branch); codeReview.Empty() {
 				destBranch, err := v.getDestBranch(&branch)
 				if err != nil {
 					return err
@@ -482,7 +680,7 @@ func (v uploadCommand) UploadForReviewWithConfirm(branchesMap map[string][]proje
 				}
 			} else {
 				fmt.Printf("Upload code review #%s of project (%s)%s:\n",
-					branch.CodeReview.ID, p.Name, draftStr)
+					codeReview.ID, p.Name, draftStr)
 			}
 			fmt.Printf("  branch %s (%2d commit(s)):\n",
 				branch.Branch.Name,
@@ -490,6 +688,18 @@ func (v uploadCommand) UploadForReviewWithConfirm(branchesMap map[string][]proje
 			for _, commit := range commitList {
 				fmt.Printf("         %s\n", commit)
 			}
+			if backports := v.backports(); len(backports) > 0 {
+				fmt.Printf("  backport to: %s\n", strings.Join(backports, ", "))
+			}
+			if !v.O.NoAutoReviewer {
+				if reviewers := branch.SuggestReviewers(); len(reviewers) > 0 {
+					fmt.Printf("  reviewers from owners: %s\n",
+						strings.Join(reviewers, ", "))
+				}
+			}
+			for _, r := range v.lintBranch(&branch) {
+				fmt.Printf("  %s\n", r)
+			}
 
 			input := userInput(
 				fmt.Sprintf("to %s (y/N)? ", remote.Review),
@@ -524,9 +734,12 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 	var (
 		projectPattern = regexp.MustCompile(`^#?\s*project\s*([^\s]+)/:$`)
 		branchPattern  = regexp.MustCompile(`^\s*branch\s*([^\s(]+)\s*\(.*`)
-		ok             bool
-		err            error
-		branchComment  string
+		// Commented branch line, which is not selected for upload.
+		skipBranchPattern = regexp.MustCompile(`^#\s*branch\s`)
+		reviewersPattern  = regexp.MustCompile(`^#\s+reviewers:\s*(.*)$`)
+		ok                bool
+		err               error
+		branchComment     string
 	)
 
 	projectsIdx := make(map[string]project.Project)
@@ -560,6 +773,7 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 		"",
 	}
 	published := true
+	gerrit := false
 	optionsFile := ""
 	for _, key := range keys {
 		branches := branchesMap[key]
@@ -577,7 +791,7 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 				script = append(script, "#")
 			}
 
-			if branch.CodeReview.Empty() {
+			if codeReview := v.reviewToUpdate(&branch); codeReview.Empty() {
 				destBranch, err := v.getDestBranch(&branch)
 				if optionsFile == "" {
 					optionsFile = destBranch
@@ -597,7 +811,7 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 						branchComment,
 						name,
 						len(commitList),
-						branch.CodeReview.ID))
+						codeReview.ID))
 
 			}
 			for i := range commitList {
@@ -608,9 +822,24 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 					script = append(script, "#         ... ...")
 				}
 			}
+			if !v.O.NoAutoReviewer {
+				if reviewers := branch.SuggestReviewers(); len(reviewers) > 0 {
+					script = append(script, fmt.Sprintf("#   reviewers: %s",
+						strings.Join(reviewers, ", ")))
+				}
+			}
+			if backports := v.backports(); len(backports) > 0 {
+				script = append(script, fmt.Sprintf("#   backport to: %s", strings.Join(backports, ", ")))
+			}
+			for _, r := range v.lintBranch(&branch) {
+				script = append(script, fmt.Sprintf("#   %s", r))
+			}
 			if !branch.IsPublished() {
 				published = false
 			}
+			if branch.Remote != nil && branch.Remote.GetType() == helper.ProtoTypeGerrit {
+				gerrit = true
+			}
 			b[name] = branch
 		}
 
@@ -626,7 +855,7 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 	optionsFile = filepath.Join(v.ws.AdminDir(), uploadOptionsDir, optionsFile)
 	path.SafeCreateParentDir(optionsFile)
 
-	script = append(v.fmtUploadOptionsScript(optionsFile, published), script...)
+	script = append(v.fmtUploadOptionsScript(optionsFile, published, gerrit), script...)
 
 	editString := editor.EditString(strings.Join(script, "\n"))
 
@@ -658,6 +887,7 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 		p                 project.Project
 		hasProject        = false
 		inBranchSelection = false
+		branchKey         string
 	)
 	for _, line := range script {
 		if !inBranchSelection {
@@ -673,6 +903,26 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 				log.Fatalf("project %s not available for upload", name)
 			}
 			hasProject = true
+			branchKey = ""
+			continue
+		}
+
+		if skipBranchPattern.MatchString(line) {
+			branchKey = ""
+			continue
+		}
+
+		// Reviewers from owners only apply to the branch above, and user
+		// may remove the line or some of the reviewers.
+		if m := reviewersPattern.FindStringSubmatch(line); m != nil {
+			if branchKey != "" {
+				for _, reviewer := range strings.Split(m[1], ",") {
+					reviewer = strings.TrimSpace(reviewer)
+					if reviewer != "" {
+						v.branchReviewers[branchKey] = append(v.branchReviewers[branchKey], reviewer)
+					}
+				}
+			}
 			continue
 		}
 
@@ -683,6 +933,8 @@ func (v uploadCommand) UploadForReviewWithEditor(branchesMap map[string][]projec
 			}
 			if branch, ok := branchesIdx[p.Name][name]; ok {
 				todo = append(todo, branch)
+				branchKey = uploadBranchKey(&branch)
+				v.branchReviewers[branchKey] = []string{}
 			} else {
 				log.Fatalf("branch %s not in %s", name, p.Path)
 			}
@@ -715,8 +967,13 @@ func (v uploadCommand) saveUploadOptions(optionsFile string, o uploadOptions) er
 		}
 	}
 
+	// Topic and hashtags are specific to one change, and a topic left
+	// over may update an unrelated code review, so they are not saved.
+	o.Topic = ""
+	o.Hashtags = nil
+
 	lockFile := optionsFile + ".lock"
-	data := strings.Join(o.Export(false), "\n")
+	data := strings.Join(o.Export(false, false), "\n")
 	err := ioutil.WriteFile(lockFile, []byte(data), 0644)
 	if err != nil {
 		return err
@@ -724,7 +981,7 @@ func (v uploadCommand) saveUploadOptions(optionsFile string, o uploadOptions) er
 	return os.Rename(lockFile, optionsFile)
 }
 
-func (v uploadCommand) fmtUploadOptionsScript(optionsFile string, published bool) []string {
+func (v uploadCommand) fmtUploadOptionsScript(optionsFile string, published, gerrit bool) []string {
 	var (
 		o      = uploadOptions{}
 		script = []string{}
@@ -816,16 +1073,262 @@ func (v uploadCommand) fmtUploadOptionsScript(optionsFile string, published bool
 		}
 	}
 
-	script = append(script, v.O.Export(published)...)
+	script = append(script, v.O.Export(published, gerrit)...)
 	return script
 }
 
+// usePicker checks whether to select branches in the interactive picker,
+// which is turned on by --picker or "upload.picker" in git config, and
+// needs a terminal, otherwise fallback to the editor script.
+func (v uploadCommand) usePicker() bool {
+	if v.O.MockPickerInput != "" {
+		return true
+	}
+	if v.O.NoPicker || v.O.MockEditScript != "" ||
+		config.AssumeYes() || config.AssumeNo() {
+		return false
+	}
+	if !v.O.Picker && !config.GitDefaultConfig.GetBool(config.CfgUploadPicker, false) {
+		return false
+	}
+	return cap.Isatty()
+}
+
+// lintBranch runs pre-upload checks on commits of branch.
+func (v uploadCommand) lintBranch(branch *project.ReviewableBranch) []project.LintResult {
+	key := uploadBranchKey(branch)
+	if results, ok := v.lintResults[key]; ok {
+		return results
+	}
+	gerrit := branch.Remote != nil && branch.Remote.GetType() == helper.ProtoTypeGerrit
+	results := branch.Lint(project.NewLintOptions(branch.Project, gerrit))
+	if v.lintResults != nil {
+		v.lintResults[key] = results
+	}
+	return results
+}
+
+// appendUniqueStrings appends items not in list yet.
+func appendUniqueStrings(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, s := range list {
+			if s == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// uploadReport is the report of upload for a branch, used by --report-json.
+type uploadReport struct {
+	Project  string                `json:"project"`
+	Path     string                `json:"path"`
+	Branch   string                `json:"branch"`
+	Uploaded bool                  `json:"uploaded"`
+	Error    string                `json:"error,omitempty"`
+	Reviews  []helper.ReviewResult `json:"reviews,omitempty"`
+}
+
+func newUploadReports(branches []project.ReviewableBranch) []uploadReport {
+	reports := []uploadReport{}
+	for _, branch := range branches {
+		report := uploadReport{
+			Project:  branch.Project.Name,
+			Path:     branch.Project.Path,
+			Branch:   branch.Branch.Name,
+			Uploaded: branch.Uploaded,
+			Reviews:  branch.Reviews,
+		}
+		if branch.Error != nil {
+			report.Error = branch.Error.Error()
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+func writeUploadReport(file string, branches []project.ReviewableBranch) error {
+	data, err := json.MarshalIndent(newUploadReports(branches), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// uploadAtomic validates all branches before upload, and stops uploading
+// on the first failure. Returns true if there are errors.
+func (v *uploadCommand) uploadAtomic(branches []project.ReviewableBranch, uploadOpts []*config.UploadOptions) bool {
+	invalid := false
+	for i := range branches {
+		branch := &(branches[i])
+		if uploadOpts[i] == nil {
+			if branch.Error == nil {
+				branch.Error = errors.New("not ready for upload")
+			}
+			invalid = true
+			continue
+		}
+		err := branch.ValidateUpload(*uploadOpts[i])
+		if err != nil {
+			branch.Error = err
+			invalid = true
+		}
+	}
+	if invalid {
+		log.Error("validation failed, nothing uploaded")
+		for i := range branches {
+			if branches[i].Error == nil {
+				branches[i].Error = errors.New("not uploaded, for other branches are invalid")
+			}
+		}
+		return true
+	}
+
+	failed := false
+	for i := range branches {
+		branch := &(branches[i])
+		if failed {
+			branch.Error = errors.New("not uploaded, for previous upload failed")
+			continue
+		}
+		err := branch.UploadForReview(uploadOpts[i])
+		if err != nil {
+			branch.Error = err
+			failed = true
+		} else {
+			branch.Uploaded = true
+		}
+	}
+	return failed
+}
+
+// uploadTopicStatusFile returns file to save status of atomic upload.
+func (v uploadCommand) uploadTopicStatusFile() string {
+	name := strings.Replace(v.O.Topic, "/", ".", -1)
+	return filepath.Join(v.ws.AdminDir(), uploadTopicsDir, name)
+}
+
+// loadUploadTopicStatus loads status of last failed atomic upload.
+func (v uploadCommand) loadUploadTopicStatus() ([]uploadReport, error) {
+	reports := []uploadReport{}
+	data, err := ioutil.ReadFile(v.uploadTopicStatusFile())
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &reports)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// saveUploadTopicStatus saves status of atomic upload for resume, and
+// removes the status file if all branches are uploaded.
+func (v uploadCommand) saveUploadTopicStatus(branches []project.ReviewableBranch, haveErrors bool) error {
+	file := v.uploadTopicStatusFile()
+	if !haveErrors {
+		if path.Exist(file) {
+			return os.Remove(file)
+		}
+		return nil
+	}
+
+	// Keep branches uploaded in previous runs.
+	reports := []uploadReport{}
+	if v.O.Resume {
+		old, err := v.loadUploadTopicStatus()
+		if err == nil {
+			for _, report := range old {
+				if !report.Uploaded {
+					continue
+				}
+				reports = append(reports, report)
+			}
+		}
+	}
+	reports = append(reports, newUploadReports(branches)...)
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	path.SafeCreateParentDir(file)
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// filterResumeTasks removes branches uploaded in last atomic upload.
+func (v uploadCommand) filterResumeTasks(tasks map[string][]project.ReviewableBranch) error {
+	reports, err := v.loadUploadTopicStatus()
+	if err != nil {
+		return fmt.Errorf("no failed upload of topic '%s' to resume", v.O.Topic)
+	}
+	uploaded := make(map[string]bool)
+	for _, report := range reports {
+		if report.Uploaded {
+			uploaded[report.Path+"\x00"+report.Branch] = true
+		}
+	}
+	for key, branches := range tasks {
+		todo := []project.ReviewableBranch{}
+		for _, branch := range branches {
+			if uploaded[branch.Project.Path+"\x00"+branch.Branch.Name] {
+				log.Notef("skip %s (%s), already uploaded", branch.Project.Path, branch.Branch.Name)
+				continue
+			}
+			todo = append(todo, branch)
+		}
+		if len(todo) == 0 {
+			delete(tasks, key)
+		} else {
+			tasks[key] = todo
+		}
+	}
+	return nil
+}
+
+// rebaseBranches rebases branches selected for upload onto the latest
+// destination branch, and marks branches which fail to rebase with errors.
+// Returns number of failures.
+func (v uploadCommand) rebaseBranches(branches []project.ReviewableBranch) int {
+	failed := 0
+	for i := range branches {
+		branch := &(branches[i])
+		rb, err := branch.Rebase(v.O.DestBranch)
+		if err != nil {
+			log.Errorf("%sskip upload of branch '%s': %s",
+				branch.Project.Prompt(),
+				branch.Branch.Name,
+				err)
+			branch.Uploaded = false
+			branch.Error = err
+			failed++
+			continue
+		}
+		*branch = *rb
+	}
+	return failed
+}
+
 func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) error {
 	var (
 		origPeople = [][]string{{}, {}}
 		oldOid     = ""
 		err        error
 		destBranch string
+		uploadOpts = make([]*config.UploadOptions, len(branches))
+		backports  = v.backports()
+		// Errors of backports, reported after reviews uploaded.
+		backportErrors = []string{}
 	)
 
 	if len(v.O.Reviewers) > 0 {
@@ -849,10 +1352,17 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 		}
 	}
 
+	// Only rebase branches confirmed for upload.
 	haveErrors := false
+	if v.O.Rebase && v.rebaseBranches(branches) > 0 {
+		haveErrors = true
+	}
 	for i := range branches {
 		// Will update branch.Error in this loop.
 		branch := &(branches[i])
+		if branch.Error != nil {
+			continue
+		}
 		theProject := branch.Project
 		remote := branch.Remote
 		if remote == nil {
@@ -865,7 +1375,31 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 		people := [][]string{{}, {}}
 		people[0] = append(people[0], origPeople[0]...)
 		people[1] = append(people[1], origPeople[1]...)
-		branch.AppendReviewers(people)
+		title := v.O.Title
+		draft := v.O.Draft
+		if opts, ok := v.branchOptions[uploadBranchKey(branch)]; ok {
+			people[0] = append([]string{}, opts.Reviewers...)
+			title = opts.Title
+			draft = opts.Draft
+		}
+		if reviewers, ok := v.branchReviewers[uploadBranchKey(branch)]; ok {
+			people[0] = appendUniqueStrings(people[0], reviewers...)
+		} else if !v.O.NoAutoReviewer {
+			people[0] = appendUniqueStrings(people[0], branch.SuggestReviewers()...)
+		}
+		err = branch.AppendReviewers(people)
+		if err != nil {
+			branch.Uploaded = false
+			branch.Error = err
+			haveErrors = true
+			continue
+		}
+		if n := project.LintErrors(v.lintBranch(branch)); n > 0 && !v.O.BypassHooks {
+			branch.Uploaded = false
+			branch.Error = fmt.Errorf("%d error(s) found by pre-upload checks, use --no-verify to bypass", n)
+			haveErrors = true
+			continue
+		}
 		cfg := theProject.ConfigWithDefault()
 		if !theProject.IsClean() {
 			key := fmt.Sprintf("review.%s.autoupload", remote.Review)
@@ -887,9 +1421,23 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 			key := fmt.Sprintf("review.%s.uploadtopic", remote.Review)
 			v.O.AutoTopic = cfg.GetBool(key, false)
 		}
+		stacked := v.O.Stacked
+		if !stacked {
+			key := fmt.Sprintf("review.%s.stacked", remote.Review)
+			stacked = cfg.GetBool(key, false)
+		}
+		autoTopic := v.O.AutoTopic
+		// Reviews of backports are linked by the same topic.
+		if len(backports) > 0 && v.O.Topic == "" {
+			autoTopic = true
+		}
 
+		codeReview := v.O.CodeReview
 		if v.O.CodeReview.Empty() {
 			oldOid = theProject.PublishedRevision(branch.Branch.Name)
+			if saved, ok := v.savedReviews[uploadBranchKey(branch)]; ok && !stacked {
+				codeReview = saved
+			}
 
 			destBranch, err = v.getDestBranch(branch)
 			if err != nil {
@@ -925,22 +1473,36 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 		}
 
 		o := config.UploadOptions{
-			AutoTopic:    v.O.AutoTopic,
-			CodeReview:   v.O.CodeReview,
-			Description:  v.O.Description,
-			DestBranch:   destBranch,
-			Draft:        v.O.Draft,
-			Issue:        v.O.Issue,
-			LocalBranch:  branch.Branch.Name,
-			MockGitPush:  v.O.MockGitPush,
-			NoCertChecks: v.O.NoCertChecks || config.NoCertChecks(),
-			NoEmails:     v.O.NoEmails,
-			OldOid:       oldOid,
-			People:       people,
-			Private:      v.O.Private,
-			PushOptions:  v.O.PushOptions,
-			Title:        v.O.Title,
-			WIP:          v.O.WIP,
+			AutoTopic:          autoTopic,
+			CodeReview:         codeReview,
+			Description:        v.O.Description,
+			DestBranch:         destBranch,
+			Draft:              draft,
+			Hashtags:           v.O.Hashtags,
+			Issue:              v.O.Issue,
+			Labels:             v.O.Labels,
+			LocalBranch:        branch.Branch.Name,
+			Message:            v.O.Message,
+			MockGitPush:        v.O.MockGitPush,
+			MockPushOutput:     v.O.MockPushOutput,
+			NoCertChecks:       v.O.NoCertChecks || config.NoCertChecks(),
+			NoEmails:           v.O.NoEmails,
+			OldOid:             oldOid,
+			People:             people,
+			Private:            v.O.Private,
+			PublishComments:    v.O.PublishComment,
+			PushOptions:        v.O.PushOptions,
+			RemoveSourceBranch: v.O.RemoveSource,
+			Stacked:            stacked,
+			Title:              title,
+			Topic:              v.O.Topic,
+			WIP:                v.O.WIP,
+		}
+
+		// Upload later after all branches are validated.
+		if v.O.Atomic {
+			uploadOpts[i] = &o
+			continue
 		}
 
 		err = branch.UploadForReview(&o)
@@ -948,13 +1510,61 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 			branch.Uploaded = false
 			branch.Error = err
 			haveErrors = true
-		} else {
-			branch.Uploaded = true
+			continue
 		}
+		branch.Uploaded = true
+
+		for _, dest := range backports {
+			err = branch.UploadBackport(o, dest)
+			if err != nil {
+				backportErrors = append(backportErrors,
+					fmt.Sprintf("[FAILED] %-15s %-15s\n       (backport to %s: %s)",
+						branch.Project.Path+"/",
+						branch.Branch.Name,
+						dest,
+						err))
+				haveErrors = true
+			}
+		}
+	}
+
+	if v.O.Atomic {
+		haveErrors = v.uploadAtomic(branches, uploadOpts)
 	}
 
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "----------------------------------------------------------------------")
+	for _, branch := range branches {
+		for _, review := range branch.Reviews {
+			link := review.URL
+			if link == "" {
+				link = "#" + review.ID
+			}
+			fmt.Fprintf(os.Stderr,
+				"[OK    ] %-15s %-15s %s\n",
+				branch.Project.Path+"/",
+				branch.Branch.Name,
+				link)
+		}
+		if v.O.Atomic && branch.Uploaded && len(branch.Reviews) == 0 {
+			fmt.Fprintf(os.Stderr,
+				"[OK    ] %-15s %s\n",
+				branch.Project.Path+"/",
+				branch.Branch.Name)
+		}
+	}
+	if v.O.Atomic {
+		err = v.saveUploadTopicStatus(branches, haveErrors)
+		if err != nil {
+			log.Warnf("fail to save status of topic '%s': %s", v.O.Topic, err)
+		}
+	}
+	if v.O.ReportJSON != "" {
+		err = writeUploadReport(v.O.ReportJSON, branches)
+		if err != nil {
+			return err
+		}
+	}
 	if haveErrors {
 		for _, branch := range branches {
 			if !branch.Uploaded && branch.Error != nil {
@@ -971,12 +1581,102 @@ func (v *uploadCommand) UploadAndReport(branches []project.ReviewableBranch) err
 					branch.Error.Error())
 			}
 		}
+		for _, msg := range backportErrors {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		if v.O.Atomic {
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintf(os.Stderr,
+				"Run \"git repo upload --topic %s --resume\" to retry failed branches.\n",
+				v.O.Topic)
+		}
 		fmt.Fprintln(os.Stderr, "")
 		os.Exit(1)
 	}
 	return nil
 }
 
+// savedCodeReview returns code review uploaded from branch last time, which
+// will be updated by this upload. Only open code reviews of AGit servers
+// are reused, for Gerrit finds code review by Change-Id.
+func (v uploadCommand) savedCodeReview(branch *project.ReviewableBranch) config.CodeReview {
+	var (
+		p      = branch.Project
+		remote = branch.Remote
+	)
+
+	if remote == nil || remote.GetType() != helper.ProtoTypeAGit {
+		return config.CodeReview{}
+	}
+	saved := p.BranchReview(branch.Branch.Name)
+	if saved.ID == "" {
+		return config.CodeReview{}
+	}
+	// Do not query code review from server in dryrun or mock mode.
+	if (config.IsDryRun() || v.O.MockGitPush) && config.GetMockReviewQueryResponse() == "" {
+		log.Debugf("%snot check code review #%s in dryrun mode", p.Prompt(), saved.ID)
+		return config.CodeReview{}
+	}
+	querier, query, err := newReviewQuerier(p, remote.Name)
+	if err != nil {
+		log.Debugf("%scannot check code review #%s: %s", p.Prompt(), saved.ID, err)
+		return config.CodeReview{}
+	}
+	review, err := querier.GetReview(query, saved.ID)
+	if err != nil {
+		log.Debugf("%scannot check code review #%s: %s", p.Prompt(), saved.ID, err)
+		return config.CodeReview{}
+	}
+	if review.Status != "" && review.Status != helper.ReviewStatusOpen {
+		log.Debugf("%scode review #%s is %s, will create new one",
+			p.Prompt(), saved.ID, review.Status)
+		return config.CodeReview{}
+	}
+	ref, _, err := remote.GetDownloadRefOptions(saved.ID, "")
+	if err != nil {
+		log.Debugf("%sfail to get ref of code review #%s: %s", p.Prompt(), saved.ID, err)
+		return config.CodeReview{}
+	}
+	log.Notef("%swill update code review #%s uploaded from branch '%s' before",
+		p.Prompt(),
+		saved.ID,
+		branch.Branch.Name)
+	return config.CodeReview{ID: saved.ID, Ref: ref}
+}
+
+// loadSavedReviews finds code reviews uploaded from branches before, which
+// are shown before confirm, and updated by this upload. Saved code reviews
+// are not reused for stacked upload, backports, or if --new-review given.
+func (v *uploadCommand) loadSavedReviews(tasks map[string][]project.ReviewableBranch) {
+	if v.O.NewReview || !v.O.CodeReview.Empty() || v.O.Stacked || len(v.backports()) > 0 {
+		return
+	}
+	for key := range tasks {
+		for i := range tasks[key] {
+			branch := &tasks[key][i]
+			if branch.Remote == nil || !branch.CodeReview.Empty() {
+				continue
+			}
+			cfg := branch.Project.ConfigWithDefault()
+			if cfg.GetBool(fmt.Sprintf("review.%s.stacked", branch.Remote.Review), false) {
+				continue
+			}
+			if codeReview := v.savedCodeReview(branch); !codeReview.Empty() {
+				v.savedReviews[uploadBranchKey(branch)] = codeReview
+			}
+		}
+	}
+}
+
+// reviewToUpdate returns code review which branch will update, or an
+// empty one if a new code review will be created.
+func (v uploadCommand) reviewToUpdate(branch *project.ReviewableBranch) config.CodeReview {
+	if !branch.CodeReview.Empty() {
+		return branch.CodeReview
+	}
+	return v.savedReviews[uploadBranchKey(branch)]
+}
+
 func (v uploadCommand) Execute(args []string) error {
 	ws := v.WorkSpace()
 	err := ws.LoadRemotes(v.O.NoCache)
@@ -987,6 +1687,27 @@ func (v uploadCommand) Execute(args []string) error {
 	if v.O.Remote != "" && !config.IsSingleMode() {
 		return fmt.Errorf("--remote can be only used with --single")
 	}
+	if v.O.Resume {
+		v.O.Atomic = true
+	}
+	if v.O.Atomic && v.O.Topic == "" {
+		return fmt.Errorf("--atomic and --resume must be used with --topic")
+	}
+	if len(v.O.DestBranches) > 0 {
+		v.O.DestBranch = v.O.DestBranches[0]
+	}
+	if len(v.backports()) > 0 {
+		if v.O.Atomic {
+			return fmt.Errorf("cannot backport to multiple branches with --atomic")
+		}
+		if v.O.CodeReview.ID != "" {
+			return fmt.Errorf("cannot backport to multiple branches when updating code review")
+		}
+	}
+	v.lintResults = make(map[string][]project.LintResult)
+	v.branchOptions = make(map[string]*uploadBranchOptions)
+	v.branchReviewers = make(map[string][]string)
+	v.savedReviews = make(map[string]config.CodeReview)
 
 	allProjects, err := ws.GetProjects(nil, args...)
 	if err != nil {
@@ -1131,12 +1852,24 @@ func (v uploadCommand) Execute(args []string) error {
 		}
 	}
 
+	if v.O.Resume {
+		err = v.filterResumeTasks(tasks)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(tasks) == 0 {
 		log.Note("no branches ready for upload")
 		return nil
 	}
+	v.loadSavedReviews(tasks)
 
-	if v.O.NoEdit || editor.Editor() == "" {
+	if v.O.NoEdit {
+		err = v.UploadForReviewWithConfirm(tasks)
+	} else if v.usePicker() {
+		err = v.UploadForReviewWithPicker(tasks)
+	} else if editor.Editor() == "" {
 		err = v.UploadForReviewWithConfirm(tasks)
 	} else {
 		err = v.UploadForReviewWithEditor(tasks)
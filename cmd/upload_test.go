@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -31,7 +34,7 @@ func TestUploadOptionsExport(t *testing.T) {
 		Issue: "123",
 	}
 
-	actual = strings.Join(o.Export(false), "\n")
+	actual = strings.Join(o.Export(false, false), "\n")
 	expect = `# [Title]       : one line message below as the title of code review
 
 Hacks from 昕希
@@ -125,3 +128,88 @@ true
 		},
 	)
 }
+
+func TestUploadOptionsGerrit(t *testing.T) {
+	var (
+		actual, expect string
+		assert         = assert.New(t)
+	)
+
+	o := uploadOptions{
+		Topic:    "my-topic",
+		Hashtags: []string{"feature", "refactor"},
+	}
+
+	actual = strings.Join(o.Export(true, true), "\n")
+	expect = `# [Issue]       : multiple lines of issue IDs for cross references
+
+# [Reviewer]    : multiple lines of user names as the reviewers for code review
+
+# [Cc]          : multiple lines of user names as the watchers for code review
+
+# [Draft]       : a boolean (yes/no, or true/false) to turn on/off draft mode
+
+# [Private]     : a boolean (yes/no, or true/false) to turn on/off private mode
+
+# [Topic]       : one line message below as the topic of code review
+
+my-topic
+
+# [Hashtag]     : multiple lines of hashtags for code review
+
+feature
+refactor
+
+# [Label]       : multiple lines of labels to vote, such as Code-Review+1
+
+# [Message]     : multiple lines of text as the message for this patch set
+
+# [Publish-Comments] : a boolean (yes/no, or true/false) to publish draft comments
+`
+	assert.Equal(expect, actual)
+
+	o = uploadOptions{}
+	o.LoadFromText(actual + `
+yes
+
+# [Label]       : multiple lines of labels to vote, such as Code-Review+1
+
+Code-Review+1
+Verified+1
+
+# [Message]     : multiple lines of text as the message for this patch set
+
+Rebased on master.
+`)
+	assert.Equal(uploadOptions{
+		Topic:          "my-topic",
+		Hashtags:       []string{"feature", "refactor"},
+		Labels:         []string{"Code-Review+1", "Verified+1"},
+		Message:        "Rebased on master.",
+		PublishComment: true,
+	}, o)
+}
+
+func TestSaveUploadOptionsWithoutTopic(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "git-repo-")
+	if !assert.Nil(err) {
+		return
+	}
+	defer os.RemoveAll(tmpdir)
+
+	optionsFile := filepath.Join(tmpdir, "UPLOAD_OPTIONS.d", "master")
+	err = uploadCommand{}.saveUploadOptions(optionsFile, uploadOptions{
+		Title:    "title",
+		Topic:    "my-topic",
+		Hashtags: []string{"feature"},
+	})
+	assert.Nil(err)
+
+	o := uploadOptions{}
+	o.LoadFromFile(optionsFile)
+	assert.Equal("title", o.Title)
+	assert.Equal("", o.Topic)
+	assert.Nil(o.Hashtags)
+}
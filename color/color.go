@@ -68,6 +68,11 @@ func colorEnabled() bool {
 	return true
 }
 
+// Enabled indicates whether to show output in color.
+func Enabled() bool {
+	return colorEnabled()
+}
+
 // Color returns color code for terminal display
 //
 // Available colors:
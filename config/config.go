@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alibaba/git-repo-go/path"
 	"github.com/jiangxin/goconfig"
@@ -61,7 +62,15 @@ const (
 	CfgBranchDefaultMerge    = "branch.default.merge"
 	CfgManifestRemoteSSHInfo = "manifest.remote.%s.sshinfo"
 	CfgManifestRemoteExpire  = "manifest.remote.%s.expire"
+	CfgManifestRemoteError   = "manifest.remote.%s.error"
+	CfgRepoSSHInfoTTL        = "repo.sshInfoTTL"
+	CfgRepoSSHInfoNegTTL     = "repo.sshInfoNegativeTTL"
+	CfgRepoURLSSHInfo        = "repo.%s.sshinfo"
+	CfgRepoURLType           = "repo.%s.type"
+	CfgRepoURLVersion        = "repo.%s.version"
+	CfgRepoURLReviewRef      = "repo.%s.reviewRef"
 	CfgAppGitRepoDisabled    = "app.git.repo.disabled"
+	CfgUploadPicker          = "upload.picker"
 
 	ManifestsDotGit  = "manifests.git"
 	Manifests        = "manifests"
@@ -71,6 +80,7 @@ const (
 	LocalManifests   = "local_manifests"
 	ProjectObjects   = "project-objects"
 	Projects         = "projects"
+	Superproject     = "superproject"
 
 	RefsHeads   = "refs/heads/"
 	RefsTags    = "refs/tags/"
@@ -78,6 +88,12 @@ const (
 	Refs        = "refs/"
 	RefsRemotes = "refs/remotes/"
 
+	// RefsNotesReviews saves code reviews of commits for stacked upload.
+	RefsNotesReviews = "refs/notes/review-ids"
+
+	// RefsReviews saves code reviews fetched by sync for offline review.
+	RefsReviews = "refs/reviews/"
+
 	MaxJobs = 32
 
 	ViperEnvPrefix = "GIT_REPO"
@@ -139,25 +155,35 @@ func GetLogLevel() string {
 
 // GetLogRotateSize gets logrotate size from config.
 func GetLogRotateSize() int64 {
-	logrotate := strings.ToLower(viper.GetString("logrotate"))
+	logrotate := viper.GetString("logrotate")
 	if logrotate == "" {
 		return 0
 	}
-	if logrotate[len(logrotate)-1] == 'b' {
-		logrotate = logrotate[0 : len(logrotate)-1]
-		if logrotate == "" {
-			return 0
-		}
+	size, err := ParseSize(logrotate)
+	if err != nil {
+		log.Warnf("bad logrotate value: %s", logrotate)
+		return 0
+	}
+	return size
+}
+
+// ParseSize parses size with an optional unit (k, m or g), such as "10m".
+func ParseSize(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s != "" && s[len(s)-1] == 'b' {
+		s = s[0 : len(s)-1]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("bad size: '%s'", value)
 	}
-	scale := logrotate[len(logrotate)-1]
+	scale := s[len(s)-1]
 	if scale == 'k' || scale == 'm' || scale == 'g' {
-		logrotate = logrotate[0 : len(logrotate)-1]
+		s = s[0 : len(s)-1]
 	}
 
-	size, err := strconv.ParseInt(logrotate, 10, 64)
+	size, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
-		log.Warnf("bad logrotate value: %s", viper.GetString("logrotate"))
-		return 0
+		return 0, fmt.Errorf("bad size: '%s'", value)
 	}
 	switch scale {
 	case 'k':
@@ -167,7 +193,7 @@ func GetLogRotateSize() int64 {
 	case 'g':
 		size <<= 30
 	}
-	return size
+	return size, nil
 }
 
 // NoCertChecks indicates whether ignore ssl cert.
@@ -175,6 +201,25 @@ func NoCertChecks() bool {
 	return !GitDefaultConfig.GetBool("http.sslverify", true)
 }
 
+// GetDuration reads duration of key from git global and system config.
+// Value of key is seconds, or duration string such as "12h". Returns
+// defaultValue if key is not set or is invalid.
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	value := GitDefaultConfig.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Warnf("bad duration of '%s': %s", key, value)
+		return defaultValue
+	}
+	return d
+}
+
 // GetMockSSHInfoStatus gets --mock-ssh-info-status option.
 func GetMockSSHInfoStatus() int {
 	return viper.GetInt("mock-ssh-info-status")
@@ -185,6 +230,11 @@ func GetMockSSHInfoResponse() string {
 	return viper.GetString("mock-ssh-info-response")
 }
 
+// GetMockReviewQueryResponse gets --mock-review-query-response option.
+func GetMockReviewQueryResponse() string {
+	return viper.GetString("mock-review-query-response")
+}
+
 // MockNoSymlink checks --mock-no-symlink option.
 func MockNoSymlink() bool {
 	return viper.GetBool("mock-no-symlink")
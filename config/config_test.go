@@ -31,3 +31,24 @@ func TestViperEnv(t *testing.T) {
 	os.Setenv(key, "false")
 	assert.False(IsSingleMode())
 }
+
+func TestParseSize(t *testing.T) {
+	assert := assert.New(t)
+
+	for value, expect := range map[string]int64{
+		"100":  100,
+		"10k":  10 << 10,
+		"10kb": 10 << 10,
+		"5M":   5 << 20,
+		"1g":   1 << 30,
+	} {
+		size, err := ParseSize(value)
+		assert.Nil(err)
+		assert.Equal(expect, size, value)
+	}
+
+	for _, value := range []string{"", "b", "10x", "m"} {
+		_, err := ParseSize(value)
+		assert.NotNil(err, value)
+	}
+}
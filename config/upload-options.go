@@ -2,22 +2,32 @@ package config
 
 // UploadOptions is options for upload related methods.
 type UploadOptions struct {
-	AutoTopic    bool
-	CodeReview   CodeReview // Directly edit remote code review.
-	Description  string
-	DestBranch   string // Target branch for code review.
-	Draft        bool
-	Issue        string
-	LocalBranch  string // Local branch with commits, will push to remote.
-	MockGitPush  bool
-	NoCertChecks bool
-	NoEmails     bool
-	OldOid       string
-	People       [][]string
-	Private      bool
-	PushOptions  []string
-	RemoteName   string
-	RemoteURL    string
-	Title        string
-	WIP          bool
+	AutoTopic          bool
+	CodeReview         CodeReview // Directly edit remote code review.
+	Commit             string     // Push this commit instead of local branch.
+	Description        string
+	DestBranch         string // Target branch for code review.
+	Draft              bool
+	Hashtags           []string
+	Issue              string
+	Labels             []string // Labels to vote, such as "Code-Review+1" (Gerrit).
+	LocalBranch        string   // Local branch with commits, will push to remote.
+	Message            string   // Message for the uploaded patch set.
+	MockGitPush        bool
+	MockPushOutput     string // File of mock git push output for test.
+	NoCertChecks       bool
+	NoEmails           bool
+	OldOid             string
+	People             [][]string
+	Private            bool
+	PublishComments    bool
+	PushOptions        []string
+	RemoteName         string
+	RemoteURL          string
+	RemoveSourceBranch bool // Remove source branch after merged (GitLab).
+	Stacked            bool // One review for each commit (AGit).
+	Title              string
+	Topic              string
+	UserEmail          string // Used to compose per-user branch to push.
+	WIP                bool
 }
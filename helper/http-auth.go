@@ -0,0 +1,279 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// urlConfigPrefix returns URL prefix in "<section>.<url>.*" of git config,
+// which has the longest match with address, like "http.<url>.*" of git.
+// Optional filter checks whether settings of the URL prefix are defined.
+func urlConfigPrefix(section, address string, filter func(prefix string) bool) string {
+	var matched string
+
+	address = strings.TrimSuffix(address, "/")
+	for _, name := range config.GitDefaultConfig.Sections() {
+		if !strings.HasPrefix(name, section+".") {
+			continue
+		}
+		prefix := strings.TrimSuffix(strings.TrimPrefix(name, section+"."), "/")
+		if prefix == "" || len(prefix) <= len(matched) {
+			continue
+		}
+		if !strings.HasPrefix(address, prefix) {
+			continue
+		}
+		// Match at boundary of path, e.g.: "https://example.com" should
+		// not match "https://example.com.cn".
+		if len(address) > len(prefix) && address[len(prefix)] != '/' {
+			continue
+		}
+		if filter != nil && !filter(prefix) {
+			continue
+		}
+		matched = prefix
+	}
+	return matched
+}
+
+// httpConfigAll returns values of "http.<url>.<key>" with the longest
+// matched URL, or values of "http.<key>" as fallback.
+func httpConfigAll(address, key string) []string {
+	prefix := urlConfigPrefix("http", address, func(prefix string) bool {
+		return config.GitDefaultConfig.HasKey("http." + prefix + "." + key)
+	})
+	if prefix != "" {
+		return config.GitDefaultConfig.GetAll("http." + prefix + "." + key)
+	}
+	return config.GitDefaultConfig.GetAll("http." + key)
+}
+
+// httpConfig returns the last value of httpConfigAll.
+func httpConfig(address, key string) string {
+	values := httpConfigAll(address, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// setHTTPExtraHeaders sets headers from "http.<url>.extraHeader" of git
+// config. Like git, an empty value resets the headers defined before.
+func setHTTPExtraHeaders(req *http.Request) {
+	var headers []string
+
+	for _, header := range httpConfigAll(req.URL.String(), "extraHeader") {
+		if header == "" {
+			headers = nil
+			continue
+		}
+		headers = append(headers, header)
+	}
+	for _, header := range headers {
+		items := strings.SplitN(header, ":", 2)
+		if len(items) != 2 {
+			log.Warnf("bad http.extraHeader: %s", header)
+			continue
+		}
+		req.Header.Add(strings.TrimSpace(items[0]), strings.TrimSpace(items[1]))
+	}
+}
+
+// setHTTPCookies sets cookies loaded from file defined in "http.cookieFile"
+// of git config. The file should be in the Netscape cookie file format.
+func setHTTPCookies(req *http.Request) {
+	cookieFile := httpConfig(req.URL.String(), "cookieFile")
+	if cookieFile == "" {
+		return
+	}
+	cookieFile, err := homedir.Expand(cookieFile)
+	if err != nil {
+		log.Warnf("bad http.cookieFile: %s", err)
+		return
+	}
+	f, err := os.Open(cookieFile)
+	if err != nil {
+		log.Warnf("fail to open http.cookieFile: %s", err)
+		return
+	}
+	defer f.Close()
+
+	host := strings.ToLower(req.URL.Hostname())
+	reqPath := req.URL.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// domain, include subdomains, path, secure, expires, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		domain := strings.ToLower(strings.TrimPrefix(fields[0], "."))
+		if host != domain &&
+			!(strings.EqualFold(fields[1], "TRUE") && strings.HasSuffix(host, "."+domain)) {
+			continue
+		}
+		if !strings.HasPrefix(reqPath, fields[2]) {
+			continue
+		}
+		if strings.EqualFold(fields[3], "TRUE") && req.URL.Scheme != "https" {
+			continue
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil &&
+			expires > 0 && time.Unix(expires, 0).Before(time.Now()) {
+			continue
+		}
+		req.AddCookie(&http.Cookie{Name: fields[5], Value: fields[6]})
+	}
+}
+
+// gitCredential runs "git credential <action>" on credential of u.
+// Returns username and password filled by git credential helpers.
+func gitCredential(action string, u *url.URL, username, password string) (string, string, error) {
+	var (
+		input  bytes.Buffer
+		output bytes.Buffer
+	)
+
+	fmt.Fprintf(&input, "protocol=%s\n", u.Scheme)
+	fmt.Fprintf(&input, "host=%s\n", u.Host)
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		fmt.Fprintf(&input, "path=%s\n", path)
+	}
+	if username != "" {
+		fmt.Fprintf(&input, "username=%s\n", username)
+	}
+	if password != "" {
+		fmt.Fprintf(&input, "password=%s\n", password)
+	}
+	input.WriteString("\n")
+
+	cmd := exec.Command(config.GIT, "credential", action)
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("fail to run git credential %s: %s", action, err)
+	}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		items := strings.SplitN(line, "=", 2)
+		if len(items) != 2 {
+			continue
+		}
+		switch items[0] {
+		case "username":
+			username = items[1]
+		case "password":
+			password = items[1]
+		}
+	}
+	return username, password, nil
+}
+
+// newHTTPRequest returns a copy of req with extra headers, cookies and
+// credentials of the request URL.
+func newHTTPRequest(req *http.Request, username, password string) (*http.Request, error) {
+	newReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		newReq.Body = body
+	}
+	setHTTPExtraHeaders(newReq)
+	setHTTPCookies(newReq)
+	if username != "" || password != "" {
+		newReq.URL.User = nil
+		newReq.SetBasicAuth(username, password)
+	}
+	return newReq, nil
+}
+
+// HTTPDo sends HTTP request using client, with extra headers and cookies
+// defined in git config ("http.<url>.extraHeader" and "http.cookieFile").
+// If the server responds 401, will retry once with credentials filled by
+// "git credential fill", and approve or reject the credentials according
+// to the response.
+func HTTPDo(client *http.Client, req *http.Request) (*http.Response, error) {
+	var username, password string
+
+	if req.URL.User != nil {
+		username = req.URL.User.Username()
+		password, _ = req.URL.User.Password()
+	}
+	newReq, err := newHTTPRequest(req, username, password)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(newReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// Only retry once, and requests with body should be replayable.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	credURL := *req.URL
+	credURL.User = nil
+	username, password, err = gitCredential("fill", &credURL, username, password)
+	if err != nil {
+		log.Debugf("%s", err)
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	log.Debugf("retry request to '%s' with credential of '%s'", credURL.String(), username)
+	newReq, err = newHTTPRequest(req, username, password)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = client.Do(newReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		_, _, err = gitCredential("reject", &credURL, username, password)
+	} else if resp.StatusCode < 300 {
+		_, _, err = gitCredential("approve", &credURL, username, password)
+	}
+	if err != nil {
+		log.Debugf("%s", err)
+	}
+	return resp, nil
+}
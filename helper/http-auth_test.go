@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPDoWithExtraHeaderAndCookies(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		cfg    = config.GitDefaultConfig
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("session")
+		if r.Header.Get("Authorization") != "Bearer token" || cookie == nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	cookieFile := filepath.Join(t.TempDir(), "cookies.txt")
+	err := ioutil.WriteFile(cookieFile, []byte(strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"127.0.0.1\tFALSE\t/\tFALSE\t0\tsession\tabc",
+		"127.0.0.1\tFALSE\t/other\tFALSE\t0\tother\txyz",
+		"",
+	}, "\n")), 0644)
+	assert.Nil(err)
+
+	keys := []string{
+		"http.extraHeader",
+		"http." + ts.URL + ".extraHeader",
+		"http.cookieFile",
+	}
+	defer func() {
+		for _, key := range keys {
+			cfg.UnsetAll(key)
+		}
+	}()
+	cfg.Set(keys[0], "Authorization: Bearer bad")
+	cfg.Set(keys[1], "Authorization: Bearer token")
+	cfg.Set(keys[2], cookieFile)
+
+	req, err := http.NewRequest("GET", ts.URL+"/ssh_info", nil)
+	assert.Nil(err)
+	resp, err := HTTPDo(http.DefaultClient, req)
+	if assert.Nil(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal("", req.Header.Get("Authorization"))
+}
+
+func TestHTTPDoRetryWithCredential(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		logFile = filepath.Join(t.TempDir(), "credential.log")
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	helper := `!f() { echo "$1" >>` + logFile + `; ` +
+		`test "$1" = get && echo username=user && echo password=${CRED_PASSWORD}; }; f`
+	for key, value := range map[string]string{
+		"GIT_CONFIG_COUNT":   "1",
+		"GIT_CONFIG_KEY_0":   "credential.helper",
+		"GIT_CONFIG_VALUE_0": helper,
+		"CRED_PASSWORD":      "secret",
+	} {
+		defer os.Unsetenv(key)
+		os.Setenv(key, value)
+	}
+
+	req, err := http.NewRequest("GET", ts.URL+"/ssh_info", nil)
+	assert.Nil(err)
+	resp, err := HTTPDo(http.DefaultClient, req)
+	if assert.Nil(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode)
+	}
+	data, _ := ioutil.ReadFile(logFile)
+	assert.Equal("get\nstore\n", string(data))
+
+	// Bad credential is rejected, and only retry once.
+	os.Remove(logFile)
+	os.Setenv("CRED_PASSWORD", "bad")
+	resp, err = HTTPDo(http.DefaultClient, req)
+	if assert.Nil(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+	data, _ = ioutil.ReadFile(logFile)
+	assert.Equal("get\nerase\n", string(data))
+}
@@ -17,6 +17,7 @@ package helper
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -54,6 +55,20 @@ func (v AGitProtoHelper) GetSSHInfo() *SSHInfo {
 	return v.sshInfo
 }
 
+// GetCapabilities returns capabilities of the helper.
+func (v AGitProtoHelper) GetCapabilities() *ProtoCapabilities {
+	return &ProtoCapabilities{
+		Protocol: ProtoHelperProtocol,
+		Upload:   true,
+		Download: true,
+		Draft:    true,
+		Private:  true,
+		WIP:      true,
+		Topic:    true,
+		Stacked:  true,
+	}
+}
+
 func (v AGitProtoHelper) supportV3() bool {
 	return v.sshInfo.ProtoVersion >= 3
 }
@@ -105,7 +120,9 @@ func (v AGitProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCom
 	refSpec := ""
 	localBranch := strings.TrimPrefix(o.LocalBranch, config.RefsHeads)
 	destBranch := strings.TrimPrefix(o.DestBranch, config.RefsHeads)
-	if localBranch == "" {
+	if o.Commit != "" {
+		refSpec = o.Commit
+	} else if localBranch == "" {
 		refSpec = "HEAD"
 	} else {
 		refSpec = config.RefsHeads + localBranch
@@ -131,10 +148,15 @@ func (v AGitProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCom
 			uploadType = "for"
 		}
 
+		// Topic is used as the session name of the code review.
+		session := localBranch
+		if o.Topic != "" {
+			session = o.Topic
+		}
 		refSpec += fmt.Sprintf(":refs/%s/%s/%s",
 			uploadType,
 			destBranch,
-			localBranch)
+			session)
 	}
 
 	if gitCanPushOptions {
@@ -222,3 +244,120 @@ func (v AGitProtoHelper) GetDownloadRefOptions(id, patch string) (string, []stri
 	}
 	return v.sshInfo.GetReviewRefOptions(id, patch)
 }
+
+// ParseReviewResults parses output of git push for code reviews.
+func (v AGitProtoHelper) ParseReviewResults(output []byte) []ReviewResult {
+	return parseReviewResults(output, reReviewURL)
+}
+
+// agitReviewInfo is code review returned by HTTP API of AGit server.
+type agitReviewInfo struct {
+	ID        int    `json:"id"`
+	Project   string `json:"project"`
+	Target    string `json:"target_branch"`
+	Topic     string `json:"topic"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Author    string `json:"author"`
+	Patchset  int    `json:"patchset"`
+	Commit    string `json:"commit"`
+	UpdatedAt string `json:"updated_at"`
+	WebURL    string `json:"web_url"`
+}
+
+// toReviewInfo converts code review of AGit server to ReviewInfo.
+func (v AGitProtoHelper) toReviewInfo(r *agitReviewInfo) ReviewInfo {
+	review := ReviewInfo{
+		ID:      strconv.Itoa(r.ID),
+		Project: r.Project,
+		Branch:  strings.TrimPrefix(r.Target, config.RefsHeads),
+		Topic:   r.Topic,
+		Subject: r.Title,
+		Owner:   r.Author,
+		Status:  strings.ToLower(r.State),
+		Commit:  r.Commit,
+		Updated: r.UpdatedAt,
+		URL:     r.WebURL,
+	}
+	if r.Patchset > 0 {
+		review.Patch = strconv.Itoa(r.Patchset)
+	}
+	review.Ref, _, _ = v.GetDownloadRefOptions(review.ID, review.Patch)
+	return review
+}
+
+// ListReviews queries code reviews using HTTP API of AGit server:
+//
+//	GET <url>/api/v1/reviews?project=<project>&owner=<owner>&status=<status>&limit=<n>
+//
+// with optional parameters "topic", "change_id" and "q", which returns a JSON array of code reviews.
+func (v AGitProtoHelper) ListReviews(q *ReviewQuery) ([]ReviewInfo, error) {
+	rootURL, err := reviewQueryURL(q)
+	if err != nil {
+		return nil, err
+	}
+	status := q.Status
+	switch status {
+	case "":
+		status = ReviewStatusOpen
+	case ReviewStatusOpen, ReviewStatusMerged, ReviewStatusClosed, ReviewStatusAll:
+	default:
+		return nil, fmt.Errorf("unknown status of code review: %s", q.Status)
+	}
+	params := url.Values{}
+	params.Set("status", status)
+	if q.Project != "" {
+		params.Set("project", q.Project)
+	}
+	if q.Owner != "" {
+		params.Set("owner", q.Owner)
+	}
+	if q.Topic != "" {
+		params.Set("topic", q.Topic)
+	}
+	if q.ChangeID != "" {
+		params.Set("change_id", q.ChangeID)
+	}
+	if q.Query != "" {
+		params.Set("q", q.Query)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	items := []agitReviewInfo{}
+	err = httpGetJSON(rootURL+"/api/v1/reviews?"+params.Encode(), &items)
+	if err != nil {
+		return nil, err
+	}
+	reviews := []ReviewInfo{}
+	for i := range items {
+		reviews = append(reviews, v.toReviewInfo(&items[i]))
+	}
+	return reviews, nil
+}
+
+// GetReview queries code review by ID using HTTP API of AGit server:
+//
+//	GET <url>/api/v1/reviews/<id>?project=<project>
+func (v AGitProtoHelper) GetReview(q *ReviewQuery, id string) (*ReviewInfo, error) {
+	rootURL, err := reviewQueryURL(q)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = strconv.Atoi(id); err != nil {
+		return nil, fmt.Errorf("bad review ID %s: %s", id, err)
+	}
+	address := rootURL + "/api/v1/reviews/" + id
+	if q.Project != "" {
+		address += "?" + url.Values{"project": {q.Project}}.Encode()
+	}
+
+	item := agitReviewInfo{}
+	err = httpGetJSON(address, &item)
+	if err != nil {
+		return nil, err
+	}
+	review := v.toReviewInfo(&item)
+	return &review, nil
+}
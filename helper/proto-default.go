@@ -41,6 +41,11 @@ func (v DefaultProtoHelper) GetSSHInfo() *SSHInfo {
 	return v.sshInfo
 }
 
+// GetCapabilities returns capabilities of the helper.
+func (v DefaultProtoHelper) GetCapabilities() *ProtoCapabilities {
+	return &ProtoCapabilities{Protocol: ProtoHelperProtocol}
+}
+
 // GetGitPushCommand reads upload options and returns git push command.
 func (v DefaultProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCommand, error) {
 	return nil, errors.New("not implement")
@@ -50,3 +55,8 @@ func (v DefaultProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPush
 func (v DefaultProtoHelper) GetDownloadRefOptions(cr, patch string) (string, []string, error) {
 	return "", nil, errors.New("not implement")
 }
+
+// ParseReviewResults parses output of git push for code reviews.
+func (v DefaultProtoHelper) ParseReviewResults(output []byte) []ReviewResult {
+	return nil
+}
@@ -21,8 +21,10 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
 )
 
 // ExternalProtoHelper implements helper for unknown remote service.
@@ -58,38 +60,112 @@ func (v *ExternalProtoHelper) Program() string {
 	return v.program
 }
 
-// GetGitPushCommand reads upload options and returns git push command.
-func (v ExternalProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCommand, error) {
-	var (
-		input   []byte
-		output  []byte
-		err     error
-		pushCmd = GitPushCommand{}
-	)
-
-	input, err = json.Marshal(o)
-	if err != nil {
-		return nil, err
-	}
+// externalCapabilities caches capabilities of external helpers.
+var externalCapabilities = sync.Map{}
 
+func (v ExternalProtoHelper) run(verb string, protocol int, input []byte) ([]byte, error) {
 	program, err := exec.LookPath(v.Program())
 	if err != nil {
 		return nil, fmt.Errorf("cannot find helper '%s'", v.Program())
 	}
 
-	cmdArgs := []string{program, "--upload"}
+	cmdArgs := []string{program, verb}
+	if protocol >= ProtoHelperProtocolV2 {
+		cmdArgs = append(cmdArgs, "--protocol", strconv.Itoa(protocol))
+	}
 	if v.sshInfo.ProtoVersion > 0 {
 		cmdArgs = append(cmdArgs, "--version", strconv.Itoa(v.sshInfo.ProtoVersion))
 	}
 	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 	cmd.Stdin = bytes.NewReader(input)
-	output, err = cmd.Output()
+	output, err := cmd.Output()
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("fail to run %s: %s", v.Program(), exitError.Stderr)
+		if exitError, ok := err.(*exec.ExitError); ok && len(exitError.Stderr) > 0 {
+			return nil, fmt.Errorf("fail to run %s: %s",
+				v.Program(),
+				strings.TrimSpace(string(exitError.Stderr)))
 		}
+		return nil, fmt.Errorf("fail to run %s: %s", v.Program(), err)
+	}
+	return output, nil
+}
+
+// request sends request in protocol version 2 to helper and parses response.
+func (v ExternalProtoHelper) request(verb string, req *ProtoRequest) (*ProtoResponse, error) {
+	req.Protocol = ProtoHelperProtocolV2
+	req.Type = v.sshInfo.ProtoType
+	req.Version = v.sshInfo.ProtoVersion
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	output, err := v.run(verb, ProtoHelperProtocolV2, input)
+	if err != nil {
+		return nil, err
+	}
+	resp := ProtoResponse{}
+	err = json.Unmarshal(output, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output from command '%s': %s", v.Program(), err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("fail to run %s: %s", v.Program(), resp.Error)
+	}
+	return &resp, nil
+}
+
+// GetCapabilities returns capabilities of the helper. Helpers which cannot
+// answer the "--capabilities" request only speak protocol version 1.
+func (v ExternalProtoHelper) GetCapabilities() *ProtoCapabilities {
+	key := v.Program() + ":" + strconv.Itoa(v.sshInfo.ProtoVersion)
+	if caps, ok := externalCapabilities.Load(key); ok {
+		return caps.(*ProtoCapabilities)
+	}
+
+	v1 := protoCapabilitiesV1
+	caps := &v1
+	resp, err := v.request("--capabilities", &ProtoRequest{})
+	if err != nil {
+		log.Debugf("fallback to protocol version 1 for helper %s: %s", v.Program(), err)
+	} else if resp.Protocol < ProtoHelperProtocolV2 || resp.Capabilities == nil {
+		log.Debugf("fallback to protocol version 1 for helper %s: bad capabilities", v.Program())
+	} else {
+		caps = resp.Capabilities
+		if caps.Protocol > ProtoHelperProtocol {
+			caps.Protocol = ProtoHelperProtocol
+		}
+	}
+	externalCapabilities.Store(key, caps)
+	return caps
+}
+
+// GetGitPushCommand reads upload options and returns git push command.
+func (v ExternalProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCommand, error) {
+	caps := v.GetCapabilities()
+	if !caps.Upload {
+		return nil, fmt.Errorf("upload is not supported by helper %s", v.Program())
 	}
 
+	if caps.Protocol >= ProtoHelperProtocolV2 {
+		resp, err := v.request("--upload", &ProtoRequest{Upload: o})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Push == nil {
+			return nil, fmt.Errorf("invalid output from command '%s': no push command", v.Program())
+		}
+		return resp.Push, nil
+	}
+
+	input, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	output, err := v.run("--upload", ProtoHelperProtocolV1, input)
+	if err != nil {
+		return nil, err
+	}
+	pushCmd := GitPushCommand{}
 	err = json.Unmarshal(output, &pushCmd)
 	if err != nil {
 		return nil, fmt.Errorf("invalid output from command '%s': %s", v.Program(), err)
@@ -99,31 +175,63 @@ func (v ExternalProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPus
 
 // GetDownloadRefOptions returns reference name of the specific code review.
 func (v ExternalProtoHelper) GetDownloadRefOptions(cr, patch string) (string, []string, error) {
+	caps := v.GetCapabilities()
+	if !caps.Download {
+		return "", nil, fmt.Errorf("download is not supported by helper %s", v.Program())
+	}
+
+	if caps.Protocol >= ProtoHelperProtocolV2 {
+		resp, err := v.request("--download", &ProtoRequest{
+			Download: &ProtoDownloadRequest{ID: cr, Patch: patch},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		if resp.Ref == "" {
+			return "", nil, fmt.Errorf("invalid output from command '%s': no reference", v.Program())
+		}
+		return resp.Ref, resp.Options, nil
+	}
+
+	output, err := v.run("--download", ProtoHelperProtocolV1, []byte(cr+" "+patch))
+	if err != nil {
+		return "", nil, err
+	}
+	return parseDownloadRefOptionsV1(v.Program(), output)
+}
+
+// parseDownloadRefOptionsV1 parses output of download in protocol version 1,
+// which is either "<ref> [<option>...]" in one line, or options in lines of
+// "-o <option>" followed by a line of reference.
+func parseDownloadRefOptionsV1(program string, output []byte) (string, []string, error) {
 	var (
 		ref     string
 		options []string
 	)
-	program, err := exec.LookPath(v.Program())
-	if err != nil {
-		return "", nil, fmt.Errorf("cannot find helper '%s'", v.Program())
-	}
 
-	cmdArgs := []string{program, "--download"}
-	if v.sshInfo.ProtoVersion > 0 {
-		cmdArgs = append(cmdArgs, "--version", strconv.Itoa(v.sshInfo.ProtoVersion))
-	}
-	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	cmd.Stdin = strings.NewReader(cr + " " + patch)
-	out, err := cmd.Output()
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return "", nil, fmt.Errorf("aaa fail to run %s: %s", v.Program(), exitError.Stderr)
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-o ") {
+			options = append(options, strings.TrimSpace(line[3:]))
+			continue
 		}
+		items := strings.Fields(line)
+		if len(items) == 0 {
+			continue
+		}
+		if ref == "" {
+			ref = items[0]
+			items = items[1:]
+		}
+		options = append(options, items...)
 	}
-	items := strings.Split(strings.TrimSpace(string(out)), " ")
-	ref = items[0]
-	if len(items) > 1 {
-		options = items[1:]
+	if ref == "" {
+		return "", nil, fmt.Errorf("invalid output from command '%s': no reference", program)
 	}
-	return ref, options, err
+	return ref, options, nil
+}
+
+// ParseReviewResults parses output of git push for code reviews.
+func (v ExternalProtoHelper) ParseReviewResults(output []byte) []ReviewResult {
+	return parseReviewResults(output, reReviewURL)
 }
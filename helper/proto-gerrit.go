@@ -17,6 +17,8 @@ package helper
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -24,6 +26,9 @@ import (
 	log "github.com/jiangxin/multi-log"
 )
 
+// gerritLabelPattern matches label to vote, such as "Code-Review+1".
+var gerritLabelPattern = regexp.MustCompile(`^[A-Za-z0-9-]+(?:[+-][0-9]+|=[+-]?[0-9]+)?$`)
+
 // GerritProtoHelper wraps helper for gerrit server.
 type GerritProtoHelper struct {
 	sshInfo *SSHInfo
@@ -50,6 +55,24 @@ func (v GerritProtoHelper) GetSSHInfo() *SSHInfo {
 	return v.sshInfo
 }
 
+// GetCapabilities returns capabilities of the helper.
+func (v GerritProtoHelper) GetCapabilities() *ProtoCapabilities {
+	return &ProtoCapabilities{
+		Protocol: ProtoHelperProtocol,
+		Upload:   true,
+		Download: true,
+		Draft:    true,
+		Private:  true,
+		WIP:      true,
+		Topic:    true,
+		Hashtag:  true,
+		Label:    true,
+		Message:  true,
+
+		PublishComments: true,
+	}
+}
+
 // GetGitPushCommand reads upload options and returns git push command.
 func (v GerritProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCommand, error) {
 	if !o.CodeReview.Empty() {
@@ -96,7 +119,9 @@ func (v GerritProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushC
 	if strings.HasPrefix(localBranch, config.RefsHeads) {
 		localBranch = strings.TrimPrefix(localBranch, config.RefsHeads)
 	}
-	if localBranch == "" {
+	if o.Commit != "" {
+		refSpec = o.Commit
+	} else if localBranch == "" {
 		refSpec = "HEAD"
 	} else {
 		refSpec = config.RefsHeads + localBranch
@@ -106,7 +131,7 @@ func (v GerritProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushC
 		uploadType,
 		destBranch)
 
-	if o.AutoTopic && localBranch != "" {
+	if o.Topic == "" && o.AutoTopic && localBranch != "" {
 		refSpec = refSpec + "/" + localBranch
 	}
 
@@ -130,6 +155,29 @@ func (v GerritProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushC
 	if o.WIP {
 		opts = append(opts, "wip")
 	}
+	// Values of topic and hashtag may have special characters, such as
+	// ",", "%" and spaces, which are percent-encoded like message.
+	if o.Topic != "" {
+		opts = append(opts, "topic="+url.QueryEscape(o.Topic))
+	}
+	for _, hashtag := range o.Hashtags {
+		opts = append(opts, "hashtag="+url.QueryEscape(hashtag))
+	}
+	// Labels are not decoded by Gerrit, reject bad labels instead.
+	for _, label := range o.Labels {
+		if !gerritLabelPattern.MatchString(label) {
+			return nil, fmt.Errorf("bad label '%s', should be <label>[+-]<score>", label)
+		}
+		opts = append(opts, "l="+label)
+	}
+	if o.Message != "" {
+		// Spaces are encoded as "+", and other special characters
+		// are percent-encoded.
+		opts = append(opts, "m="+url.QueryEscape(o.Message))
+	}
+	if o.PublishComments {
+		opts = append(opts, "publish-comments")
+	}
 	if len(opts) > 0 {
 		refSpec = refSpec + "%" + strings.Join(opts, ",")
 	}
@@ -167,3 +215,152 @@ func (v GerritProtoHelper) GetDownloadRefOptions(cr, patch string) (string, []st
 	}
 	return v.sshInfo.GetReviewRefOptions(cr, patch)
 }
+
+// ParseReviewResults parses output of git push for code reviews.
+func (v GerritProtoHelper) ParseReviewResults(output []byte) []ReviewResult {
+	return parseReviewResults(output, reReviewURL)
+}
+
+// gerritChangeInfo is ChangeInfo entity of Gerrit REST API.
+type gerritChangeInfo struct {
+	Number          int    `json:"_number"`
+	Project         string `json:"project"`
+	Branch          string `json:"branch"`
+	Topic           string `json:"topic"`
+	Subject         string `json:"subject"`
+	Status          string `json:"status"`
+	Updated         string `json:"updated"`
+	CurrentRevision string `json:"current_revision"`
+	Owner           struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Username string `json:"username"`
+	} `json:"owner"`
+	Revisions map[string]struct {
+		Number int    `json:"_number"`
+		Ref    string `json:"ref"`
+	} `json:"revisions"`
+}
+
+// toReviewInfo converts Gerrit ChangeInfo to ReviewInfo.
+func (v GerritProtoHelper) toReviewInfo(rootURL string, change *gerritChangeInfo) ReviewInfo {
+	review := ReviewInfo{
+		ID:      strconv.Itoa(change.Number),
+		Project: change.Project,
+		Branch:  change.Branch,
+		Topic:   change.Topic,
+		Subject: change.Subject,
+		Commit:  change.CurrentRevision,
+		URL:     fmt.Sprintf("%s/c/%s/+/%d", rootURL, change.Project, change.Number),
+	}
+	switch change.Status {
+	case "NEW":
+		review.Status = ReviewStatusOpen
+	case "MERGED":
+		review.Status = ReviewStatusMerged
+	case "ABANDONED":
+		review.Status = ReviewStatusClosed
+	default:
+		review.Status = strings.ToLower(change.Status)
+	}
+	// Timestamp is in format of "2006-01-02 15:04:05.000000000" (UTC).
+	if len(change.Updated) > 19 {
+		review.Updated = change.Updated[:19]
+	} else {
+		review.Updated = change.Updated
+	}
+	if change.Owner.Email != "" {
+		review.Owner = change.Owner.Email
+	} else if change.Owner.Username != "" {
+		review.Owner = change.Owner.Username
+	} else {
+		review.Owner = change.Owner.Name
+	}
+	if rev, ok := change.Revisions[change.CurrentRevision]; ok {
+		review.Patch = strconv.Itoa(rev.Number)
+		review.Ref = rev.Ref
+	}
+	if review.Ref == "" && review.Patch != "" {
+		review.Ref, _, _ = v.GetDownloadRefOptions(review.ID, review.Patch)
+	}
+	return review
+}
+
+// ListReviews queries code reviews using Gerrit REST API.
+func (v GerritProtoHelper) ListReviews(q *ReviewQuery) ([]ReviewInfo, error) {
+	rootURL, err := reviewQueryURL(q)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []string{}
+	switch q.Status {
+	case "", ReviewStatusOpen:
+		terms = append(terms, "status:open")
+	case ReviewStatusMerged:
+		terms = append(terms, "status:merged")
+	case ReviewStatusClosed:
+		terms = append(terms, "status:abandoned")
+	case ReviewStatusAll:
+	default:
+		return nil, fmt.Errorf("unknown status of code review: %s", q.Status)
+	}
+	if q.Project != "" {
+		terms = append(terms, "project:"+q.Project)
+	}
+	if q.Owner != "" {
+		terms = append(terms, "owner:"+q.Owner)
+	}
+	if q.Topic != "" {
+		terms = append(terms, "topic:"+q.Topic)
+	}
+	if q.ChangeID != "" {
+		terms = append(terms, "change:"+q.ChangeID)
+	}
+	if q.Query != "" {
+		terms = append(terms, q.Query)
+	}
+	address := fmt.Sprintf("%s/changes/?q=%s&o=CURRENT_REVISION&o=DETAILED_ACCOUNTS",
+		rootURL,
+		url.QueryEscape(strings.Join(terms, " ")))
+	if q.Limit > 0 {
+		address += fmt.Sprintf("&n=%d", q.Limit)
+	}
+
+	changes := []gerritChangeInfo{}
+	err = httpGetJSON(address, &changes)
+	if err != nil {
+		return nil, err
+	}
+	reviews := []ReviewInfo{}
+	for i := range changes {
+		reviews = append(reviews, v.toReviewInfo(rootURL, &changes[i]))
+	}
+	return reviews, nil
+}
+
+// GetReview queries code review by ID using Gerrit REST API.
+func (v GerritProtoHelper) GetReview(q *ReviewQuery, id string) (*ReviewInfo, error) {
+	rootURL, err := reviewQueryURL(q)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = strconv.Atoi(id); err != nil {
+		return nil, fmt.Errorf("bad review ID %s: %s", id, err)
+	}
+	changeID := id
+	if q.Project != "" {
+		changeID = url.PathEscape(q.Project) + "~" + id
+	}
+	address := fmt.Sprintf("%s/changes/%s?o=CURRENT_REVISION&o=DETAILED_ACCOUNTS",
+		rootURL,
+		changeID)
+
+	change := gerritChangeInfo{}
+	err = httpGetJSON(address, &change)
+	if err != nil {
+		return nil, err
+	}
+	review := v.toReviewInfo(rootURL, &change)
+	return &review, nil
+}
@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGerritGetGitPushCommand(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		helper = NewGerritProtoHelper(&SSHInfo{})
+	)
+
+	tests := []struct {
+		o       config.UploadOptions
+		refSpec string
+		err     string
+	}{
+		{
+			o:       config.UploadOptions{},
+			refSpec: "refs/heads/my/topic:refs/for/master",
+		},
+		{
+			o:       config.UploadOptions{Topic: "feature-x"},
+			refSpec: "refs/heads/my/topic:refs/for/master%topic=feature-x",
+		},
+		{
+			o:       config.UploadOptions{Topic: "a,b c%d+e"},
+			refSpec: "refs/heads/my/topic:refs/for/master%topic=a%2Cb+c%25d%2Be",
+		},
+		{
+			o:       config.UploadOptions{Hashtags: []string{"bug fix", "a,b"}},
+			refSpec: "refs/heads/my/topic:refs/for/master%hashtag=bug+fix,hashtag=a%2Cb",
+		},
+		{
+			o:       config.UploadOptions{Labels: []string{"Code-Review+1", "Verified-1", "Custom=+2"}},
+			refSpec: "refs/heads/my/topic:refs/for/master%l=Code-Review+1,l=Verified-1,l=Custom=+2",
+		},
+		{
+			o:   config.UploadOptions{Labels: []string{"Code-Review+1,r=user"}},
+			err: "bad label 'Code-Review+1,r=user', should be <label>[+-]<score>",
+		},
+		{
+			o:       config.UploadOptions{Message: "fix, 100% done"},
+			refSpec: "refs/heads/my/topic:refs/for/master%m=fix%2C+100%25+done",
+		},
+	}
+
+	for _, test := range tests {
+		o := test.o
+		o.RemoteURL = "https://gerrit.example.com/project"
+		o.RemoteName = "origin"
+		o.DestBranch = "master"
+		o.LocalBranch = "my/topic"
+		cmd, err := helper.GetGitPushCommand(&o)
+		if test.err != "" {
+			if assert.NotNil(err) {
+				assert.Equal(test.err, err.Error())
+			}
+			continue
+		}
+		if assert.Nil(err) {
+			assert.Equal([]string{"push", "origin", test.refSpec}, cmd.Args)
+		}
+	}
+}
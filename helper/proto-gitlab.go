@@ -0,0 +1,205 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/cap"
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+)
+
+// GitLabProtoHelper implements helper for GitLab server, which creates
+// merge requests using push options.
+type GitLabProtoHelper struct {
+	sshInfo *SSHInfo
+}
+
+// NewGitLabProtoHelper returns GitLabProtoHelper object.
+func NewGitLabProtoHelper(sshInfo *SSHInfo) *GitLabProtoHelper {
+	if sshInfo.ReviewRefPattern == "" {
+		sshInfo.ReviewRefPattern = "refs/merge-requests/{id}/head"
+	}
+	return &GitLabProtoHelper{sshInfo: sshInfo}
+}
+
+// GetType returns remote server type.
+func (v GitLabProtoHelper) GetType() string {
+	return ProtoTypeGitLab
+}
+
+// GetSSHInfo returns SSHInfo object.
+func (v GitLabProtoHelper) GetSSHInfo() *SSHInfo {
+	return v.sshInfo
+}
+
+// GetCapabilities returns capabilities of the helper.
+func (v GitLabProtoHelper) GetCapabilities() *ProtoCapabilities {
+	return &ProtoCapabilities{
+		Protocol: ProtoHelperProtocol,
+		Upload:   true,
+		Download: true,
+		Draft:    true,
+		WIP:      true,
+		Topic:    true,
+		Hashtag:  true,
+	}
+}
+
+// gitlabPushOptionValue removes newlines from value of push options,
+// which are not allowed by git. GitLab renders "<br>" as a line break.
+func gitlabPushOptionValue(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	return strings.Replace(value, "\n", "<br>", -1)
+}
+
+// GetGitPushCommand reads upload options and returns git push command.
+//
+// Commits are pushed to a per-user branch (<login>/<topic>, and topic
+// defaults to the local branch), and a merge request is created from this
+// branch using push options. Upload again to update the merge request.
+func (v GitLabProtoHelper) GetGitPushCommand(o *config.UploadOptions) (*GitPushCommand, error) {
+	var (
+		gitPushCmd = GitPushCommand{}
+	)
+
+	cmds := []string{"git", "push"}
+
+	if o.RemoteURL == "" {
+		return nil, errors.New("empty review url for helper")
+	}
+	gitURL := config.ParseGitURL(o.RemoteURL)
+	if gitURL == nil || (gitURL.Proto != "ssh" && gitURL.Proto != "http" && gitURL.Proto != "https") {
+		return nil, fmt.Errorf("bad review URL: %s", o.RemoteURL)
+	}
+
+	if !o.CodeReview.Empty() {
+		return nil, fmt.Errorf("cannot change merge request %s directly, upload the source branch again instead",
+			o.CodeReview.ID)
+	}
+	if !cap.GitCanPushOptions() {
+		return nil, errors.New("cannot send push options, for your git version is too low")
+	}
+
+	localBranch := strings.TrimPrefix(o.LocalBranch, config.RefsHeads)
+	destBranch := strings.TrimPrefix(o.DestBranch, config.RefsHeads)
+	if localBranch == "" {
+		return nil, errors.New("cannot create merge request from detached HEAD")
+	}
+	if destBranch == "" {
+		return nil, errors.New("no destination for merge request")
+	}
+	// Topic is used as the name of source branch.
+	sourceBranch := localBranch
+	if o.Topic != "" {
+		sourceBranch = o.Topic
+	}
+	// Commit other than the local branch (such as a backport) needs its
+	// own source branch for another destination.
+	if o.Commit != "" {
+		sourceBranch += "-" + strings.Replace(destBranch, "/", "-", -1)
+	}
+	if login := GetLoginFromEmail(o.UserEmail); login != "" {
+		sourceBranch = login + "/" + sourceBranch
+	}
+
+	for _, pushOption := range o.PushOptions {
+		cmds = append(cmds, "-o", pushOption)
+	}
+	cmds = append(cmds,
+		"-o", "merge_request.create",
+		"-o", "merge_request.target="+destBranch,
+	)
+	if o.Title != "" {
+		cmds = append(cmds, "-o", "merge_request.title="+gitlabPushOptionValue(o.Title))
+	}
+	if o.Description != "" {
+		cmds = append(cmds, "-o", "merge_request.description="+gitlabPushOptionValue(o.Description))
+	}
+	if o.Draft || o.WIP {
+		cmds = append(cmds, "-o", "merge_request.draft")
+	}
+	// GitLab assigns merge request by username, not by email.
+	if o.People != nil && len(o.People) > 0 {
+		for _, u := range o.People[0] {
+			if login := GetLoginFromEmail(u); login != "" {
+				u = login
+			}
+			cmds = append(cmds, "-o", "merge_request.assign="+u)
+		}
+	}
+	// Hashtags are mapped to labels of merge request.
+	for _, hashtag := range o.Hashtags {
+		cmds = append(cmds, "-o", "merge_request.label="+gitlabPushOptionValue(hashtag))
+	}
+	if o.RemoveSourceBranch {
+		cmds = append(cmds, "-o", "merge_request.remove_source_branch")
+	}
+
+	if o.People != nil && len(o.People) > 1 && len(o.People[1]) > 0 {
+		log.Warnf("cc is not supported by gitlab, ignored: %s", strings.Join(o.People[1], ","))
+	}
+	if o.Issue != "" {
+		log.Warnf("issue is not supported by gitlab, ignored: %s", o.Issue)
+	}
+	if o.Private {
+		log.Warn("private merge request is not supported by gitlab, ignored")
+	}
+	if o.NoEmails {
+		log.Warn("cannot disable notification emails for gitlab, ignored")
+	}
+
+	if o.RemoteName != "" {
+		cmds = append(cmds, o.RemoteName)
+	} else {
+		cmds = append(cmds, o.RemoteURL)
+	}
+	// Force push, for the source branch is rewritten when commits are amended.
+	source := config.RefsHeads + localBranch
+	if o.Commit != "" {
+		source = o.Commit
+	}
+	cmds = append(cmds, fmt.Sprintf("+%s:%s%s",
+		source,
+		config.RefsHeads,
+		sourceBranch))
+
+	gitPushCmd.Cmd = cmds[0]
+	gitPushCmd.Args = cmds[1:]
+	return &gitPushCmd, nil
+}
+
+// GetDownloadRefOptions returns reference name of the specific code review.
+// Patch set is not supported, and the head of merge request is used.
+func (v GitLabProtoHelper) GetDownloadRefOptions(id, patch string) (string, []string, error) {
+	_, err := strconv.Atoi(id)
+	if err != nil {
+		return "", nil, fmt.Errorf("bad review ID %s: %s", id, err)
+	}
+	if patch != "" && patch != "0" {
+		log.Warnf("patch set %s is ignored, download head of merge request %s", patch, id)
+	}
+	return v.sshInfo.GetReviewRefOptions(id, "")
+}
+
+// ParseReviewResults parses output of git push for code reviews.
+func (v GitLabProtoHelper) ParseReviewResults(output []byte) []ReviewResult {
+	return parseReviewResults(output, reGitLabReviewURL)
+}
@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/alibaba/git-repo-go/cap"
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitLabGetGitPushCommand(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		helper = NewGitLabProtoHelper(&SSHInfo{})
+	)
+
+	if !cap.GitCanPushOptions() {
+		t.Skip("git cannot send push options")
+	}
+
+	tests := []struct {
+		o    config.UploadOptions
+		args []string
+	}{
+		{
+			o: config.UploadOptions{},
+			args: []string{
+				"-o", "merge_request.create",
+				"-o", "merge_request.target=master",
+				"origin",
+				"+refs/heads/my/topic:refs/heads/jiangxin/my/topic",
+			},
+		},
+		{
+			o: config.UploadOptions{Topic: "feature-x"},
+			args: []string{
+				"-o", "merge_request.create",
+				"-o", "merge_request.target=master",
+				"origin",
+				"+refs/heads/my/topic:refs/heads/jiangxin/feature-x",
+			},
+		},
+		{
+			o: config.UploadOptions{
+				DestBranch: "release/1.0",
+				Commit:     "0123456789abcdef0123456789abcdef01234567",
+				Topic:      "feature-x",
+			},
+			args: []string{
+				"-o", "merge_request.create",
+				"-o", "merge_request.target=release/1.0",
+				"origin",
+				"+0123456789abcdef0123456789abcdef01234567:refs/heads/jiangxin/feature-x-release-1.0",
+			},
+		},
+		{
+			o: config.UploadOptions{
+				People: [][]string{{"alice@example.com", "Bob <bob@example.com>", "carol"}},
+			},
+			args: []string{
+				"-o", "merge_request.create",
+				"-o", "merge_request.target=master",
+				"-o", "merge_request.assign=alice",
+				"-o", "merge_request.assign=bob",
+				"-o", "merge_request.assign=carol",
+				"origin",
+				"+refs/heads/my/topic:refs/heads/jiangxin/my/topic",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		o := test.o
+		o.RemoteURL = "https://gitlab.example.com/project"
+		o.RemoteName = "origin"
+		if o.DestBranch == "" {
+			o.DestBranch = "master"
+		}
+		o.LocalBranch = "my/topic"
+		o.UserEmail = "jiangxin@example.com"
+		cmd, err := helper.GetGitPushCommand(&o)
+		if assert.Nil(err) {
+			assert.Equal(append([]string{"push"}, test.args...), cmd.Args)
+		}
+	}
+}
@@ -16,16 +16,34 @@ package helper
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"strings"
 
 	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
 )
 
 // Proto types
 const (
 	ProtoTypeAGit   = "agit"
 	ProtoTypeGerrit = "gerrit"
+	ProtoTypeGitLab = "gitlab"
+)
+
+// Protocol versions between git-repo and proto helpers.
+//
+// Version 1: upload options in JSON are sent to helper for upload, and
+// "<id> <patch>" is sent for download. Helper outputs git push command
+// in JSON for upload, and reference name and options for download.
+//
+// Version 2: both request and response are in JSON (see ProtoRequest and
+// ProtoResponse), and helper can declare its capabilities.
+const (
+	ProtoHelperProtocolV1 = 1
+	ProtoHelperProtocolV2 = 2
+
+	ProtoHelperProtocol = ProtoHelperProtocolV2
 )
 
 // GitPushCommand holds command and args for git command.
@@ -36,12 +54,121 @@ type GitPushCommand struct {
 	GitConfig []string `json:"gitconfig,omitempty"`
 }
 
+// ProtoCapabilities defines what a proto helper supports.
+type ProtoCapabilities struct {
+	Protocol int  `json:"protocol"`
+	Upload   bool `json:"upload,omitempty"`
+	Download bool `json:"download,omitempty"`
+	Draft    bool `json:"draft,omitempty"`
+	Private  bool `json:"private,omitempty"`
+	WIP      bool `json:"wip,omitempty"`
+	Topic    bool `json:"topic,omitempty"`
+	Hashtag  bool `json:"hashtag,omitempty"`
+	Label    bool `json:"label,omitempty"`
+	Message  bool `json:"message,omitempty"`
+	Stacked  bool `json:"stacked,omitempty"`
+
+	PublishComments bool `json:"publish-comments,omitempty"`
+}
+
+// protoCapabilitiesV1 is used for helpers only speak protocol version 1,
+// which cannot tell what they support, so assume they support all except
+// stacked reviews, which needs to push a commit instead of a branch.
+var protoCapabilitiesV1 = ProtoCapabilities{
+	Protocol: ProtoHelperProtocolV1,
+	Upload:   true,
+	Download: true,
+	Draft:    true,
+	Private:  true,
+	WIP:      true,
+	Topic:    true,
+	Hashtag:  true,
+	Label:    true,
+	Message:  true,
+
+	PublishComments: true,
+}
+
+// CheckUploadOptions warns and turns off upload options which are not
+// supported by the proto helper.
+func (v ProtoCapabilities) CheckUploadOptions(o *config.UploadOptions, protoType string) {
+	if protoType == "" {
+		protoType = "remote"
+	}
+	if o.Draft && !v.Draft {
+		log.Warnf("draft is not supported by %s, ignored", protoType)
+		o.Draft = false
+	}
+	if o.Private && !v.Private {
+		log.Warnf("private is not supported by %s, ignored", protoType)
+		o.Private = false
+	}
+	if o.WIP && !v.WIP {
+		log.Warnf("wip is not supported by %s, ignored", protoType)
+		o.WIP = false
+	}
+	if (o.AutoTopic || o.Topic != "") && !v.Topic {
+		log.Warnf("topic is not supported by %s, ignored", protoType)
+		o.AutoTopic = false
+		o.Topic = ""
+	}
+	if len(o.Hashtags) > 0 && !v.Hashtag {
+		log.Warnf("hashtag is not supported by %s, ignored", protoType)
+		o.Hashtags = nil
+	}
+	if len(o.Labels) > 0 && !v.Label {
+		log.Warnf("label is not supported by %s, ignored", protoType)
+		o.Labels = nil
+	}
+	if o.Message != "" && !v.Message {
+		log.Warnf("message is not supported by %s, ignored", protoType)
+		o.Message = ""
+	}
+	if o.PublishComments && !v.PublishComments {
+		log.Warnf("publish-comments is not supported by %s, ignored", protoType)
+		o.PublishComments = false
+	}
+	if o.Stacked && !v.Stacked {
+		log.Warnf("stacked reviews are not supported by %s, upload as one review", protoType)
+		o.Stacked = false
+	}
+}
+
+// ProtoDownloadRequest is the download request sent to proto helper.
+type ProtoDownloadRequest struct {
+	ID    string `json:"id"`
+	Patch string `json:"patch,omitempty"`
+}
+
+// ProtoRequest is the request sent to proto helper (protocol version 2).
+// The action is given by the command line option of the helper:
+// "--upload", "--download" or "--capabilities".
+type ProtoRequest struct {
+	Protocol int                   `json:"protocol"`
+	Type     string                `json:"type,omitempty"`
+	Version  int                   `json:"version,omitempty"`
+	Upload   *config.UploadOptions `json:"upload,omitempty"`
+	Download *ProtoDownloadRequest `json:"download,omitempty"`
+}
+
+// ProtoResponse is the response of proto helper (protocol version 2).
+type ProtoResponse struct {
+	Protocol     int                `json:"protocol"`
+	Error        string             `json:"error,omitempty"`
+	Capabilities *ProtoCapabilities `json:"capabilities,omitempty"`
+	Push         *GitPushCommand    `json:"push,omitempty"`
+	Ref          string             `json:"ref,omitempty"`
+	Options      []string           `json:"options,omitempty"`
+}
+
 // ProtoHelper defines interface for proto helper.
 type ProtoHelper interface {
 	GetType() string
 	GetSSHInfo() *SSHInfo
+	GetCapabilities() *ProtoCapabilities
 	GetGitPushCommand(*config.UploadOptions) (*GitPushCommand, error)
 	GetDownloadRefOptions(string, string) (string, []string, error)
+	ParseReviewResults([]byte) []ReviewResult
 }
 
 // NewProtoHelper returns proto helper for specific proto type.
@@ -51,6 +178,8 @@ func NewProtoHelper(sshInfo *SSHInfo) ProtoHelper {
 		return NewAGitProtoHelper(sshInfo)
 	case ProtoTypeGerrit:
 		return NewGerritProtoHelper(sshInfo)
+	case ProtoTypeGitLab:
+		return NewGitLabProtoHelper(sshInfo)
 	case "":
 		return NewDefaultProtoHelper(sshInfo)
 	}
@@ -58,22 +187,70 @@ func NewProtoHelper(sshInfo *SSHInfo) ProtoHelper {
 }
 
 // GetGitPushCommandPipe reads JSON from STDIN, pipe it to the helper, and
-// output the result in JSON.
-func GetGitPushCommandPipe(proto ProtoHelper) ([]byte, error) {
+// output the result in JSON of the given protocol version.
+func GetGitPushCommandPipe(proto ProtoHelper, protocol int) ([]byte, error) {
 	var (
 		o   = config.UploadOptions{}
 		err error
 	)
 
 	decoder := json.NewDecoder(os.Stdin)
-	err = decoder.Decode(&o)
+	if protocol < ProtoHelperProtocolV2 {
+		err = decoder.Decode(&o)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		req := ProtoRequest{}
+		err = decoder.Decode(&req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Upload == nil {
+			return nil, errors.New("no upload options in request")
+		}
+		o = *req.Upload
+	}
+
+	cmd, err := proto.GetGitPushCommand(&o)
 	if err != nil {
 		return nil, err
 	}
+	if protocol < ProtoHelperProtocolV2 {
+		return json.MarshalIndent(&cmd, "", "\t")
+	}
+	return json.MarshalIndent(&ProtoResponse{
+		Protocol: ProtoHelperProtocol,
+		Push:     cmd,
+	}, "", "\t")
+}
 
-	cmd, err := proto.GetGitPushCommand(&o)
+// GetDownloadRefOptionsPipe reads download request (protocol version 2)
+// from STDIN, pipe it to the helper, and output the result in JSON.
+func GetDownloadRefOptionsPipe(proto ProtoHelper) ([]byte, error) {
+	req := ProtoRequest{}
+	err := json.NewDecoder(os.Stdin).Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Download == nil {
+		return nil, errors.New("no download options in request")
+	}
+	ref, options, err := proto.GetDownloadRefOptions(req.Download.ID, req.Download.Patch)
 	if err != nil {
 		return nil, err
 	}
-	return json.MarshalIndent(&cmd, "", "\t")
+	return json.MarshalIndent(&ProtoResponse{
+		Protocol: ProtoHelperProtocol,
+		Ref:      ref,
+		Options:  options,
+	}, "", "\t")
+}
+
+// GetCapabilitiesPipe outputs capabilities of the helper in JSON.
+func GetCapabilitiesPipe(proto ProtoHelper) ([]byte, error) {
+	return json.MarshalIndent(&ProtoResponse{
+		Protocol:     ProtoHelperProtocol,
+		Capabilities: proto.GetCapabilities(),
+	}, "", "\t")
 }
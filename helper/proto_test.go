@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDownloadRefOptionsV1(t *testing.T) {
+	var (
+		ref string
+		err error
+		o   []string
+	)
+
+	assert := assert.New(t)
+
+	ref, o, err = parseDownloadRefOptionsV1("helper", []byte("refs/changes/45/12345/1\n"))
+	assert.Nil(err)
+	assert.Equal("refs/changes/45/12345/1", ref)
+	assert.Empty(o)
+
+	ref, o, err = parseDownloadRefOptionsV1("helper", []byte("refs/changes/123/head review=123\n"))
+	assert.Nil(err)
+	assert.Equal("refs/changes/123/head", ref)
+	assert.Equal([]string{"review=123"}, o)
+
+	ref, o, err = parseDownloadRefOptionsV1("helper", []byte("-o review=123\nrefs/changes/123/head\n"))
+	assert.Nil(err)
+	assert.Equal("refs/changes/123/head", ref)
+	assert.Equal([]string{"review=123"}, o)
+
+	_, _, err = parseDownloadRefOptionsV1("helper", []byte("\n"))
+	assert.Equal("invalid output from command 'helper': no reference", err.Error())
+}
+
+func TestCheckUploadOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	o := config.UploadOptions{
+		AutoTopic: true,
+		Draft:     true,
+		Labels:    []string{"Code-Review+1"},
+		Private:   true,
+		Stacked:   true,
+		WIP:       true,
+	}
+	NewGitLabProtoHelper(&SSHInfo{}).GetCapabilities().CheckUploadOptions(&o, ProtoTypeGitLab)
+	assert.True(o.AutoTopic)
+	assert.Nil(o.Labels)
+	assert.True(o.Draft)
+	assert.False(o.Private)
+	assert.False(o.Stacked)
+	assert.True(o.WIP)
+
+	o = config.UploadOptions{
+		Stacked: true,
+	}
+	NewAGitProtoHelper(&SSHInfo{}).GetCapabilities().CheckUploadOptions(&o, ProtoTypeAGit)
+	assert.True(o.Stacked)
+
+	o = config.UploadOptions{
+		AutoTopic: true,
+		Draft:     true,
+		Private:   true,
+		Stacked:   true,
+		WIP:       true,
+	}
+	protoCapabilitiesV1.CheckUploadOptions(&o, "unknown")
+	assert.True(o.AutoTopic)
+	assert.True(o.Draft)
+	assert.True(o.Private)
+	assert.False(o.Stacked)
+	assert.True(o.WIP)
+}
+
+func TestParseReviewResults(t *testing.T) {
+	assert := assert.New(t)
+
+	output := []byte(`To ssh://gerrit.example.com:29418/project
+remote: Processing changes: new: 2, done
+remote:
+remote: New Changes:
+remote:   https://gerrit.example.com/c/project/+/12345 first commit [NEW]
+remote:   https://gerrit.example.com/c/project/+/12346 second commit [NEW]
+remote:
+ * [new reference]   my/topic -> refs/for/master
+`)
+	assert.Equal([]ReviewResult{
+		{ID: "12345", URL: "https://gerrit.example.com/c/project/+/12345"},
+		{ID: "12346", URL: "https://gerrit.example.com/c/project/+/12346"},
+	}, NewGerritProtoHelper(&SSHInfo{}).ParseReviewResults(output))
+
+	output = []byte(`remote: +------------------------------------------------------------------------+
+remote: | Merge request #123 was created or updated.                             |
+remote: | View merge request for details:                                        |
+remote: |   https://example.com/project/merge_request/123                        |
+remote: +------------------------------------------------------------------------+
+`)
+	assert.Equal([]ReviewResult{
+		{ID: "123", URL: "https://example.com/project/merge_request/123"},
+	}, NewAGitProtoHelper(&SSHInfo{}).ParseReviewResults(output))
+
+	output = []byte(`remote:
+remote: To create a merge request for main, visit:
+remote:   https://gitlab.example.com/group/project/-/merge_requests/new?merge_request%5Bsource_branch%5D=main
+remote:
+remote: View merge request for my/topic:
+remote:   https://gitlab.example.com/group/project/-/merge_requests/42
+remote:
+`)
+	assert.Equal([]ReviewResult{
+		{ID: "42", URL: "https://gitlab.example.com/group/project/-/merge_requests/42"},
+	}, NewGitLabProtoHelper(&SSHInfo{}).ParseReviewResults(output))
+
+	assert.Nil(NewAGitProtoHelper(&SSHInfo{}).ParseReviewResults([]byte("Everything up-to-date\n")))
+}
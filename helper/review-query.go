@@ -0,0 +1,181 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/h2non/gock"
+	log "github.com/jiangxin/multi-log"
+)
+
+// Status of code review.
+const (
+	ReviewStatusOpen   = "open"
+	ReviewStatusMerged = "merged"
+	ReviewStatusClosed = "closed"
+	ReviewStatusAll    = "all"
+)
+
+// gerritXSSIPrefix is prepended to JSON response of Gerrit REST API.
+const gerritXSSIPrefix = ")]}'"
+
+var (
+	// reGerritChangeURL matches URL of Gerrit change, such as:
+	// "https://gerrit.example.com/c/project/+/12345/2", or
+	// "https://gerrit.example.com/#/c/project/+/12345".
+	reGerritChangeURL = regexp.MustCompile(`^(https?://([^/]+)(?:/[^#]*?)?)/(?:#/)?c/(.+)/\+/([0-9]+)(?:/([0-9]+))?/?$`)
+
+	// reAGitReviewURL matches URL of merge request of AGit, such as:
+	// "https://example.com/project/merge_request/123".
+	reAGitReviewURL = regexp.MustCompile(`^(https?://([^/]+))/(.+?)(?:/-)?/(?:merge_requests?|change)/([0-9]+)(?:/([0-9]+))?/?$`)
+)
+
+// ReviewInfo holds status of a code review queried from server.
+type ReviewInfo struct {
+	ID      string `json:"id"`
+	Project string `json:"project,omitempty"`
+	Branch  string `json:"branch,omitempty"` // Destination branch.
+	Topic   string `json:"topic,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Patch   string `json:"patch,omitempty"` // Latest patch set.
+	Ref     string `json:"ref,omitempty"`   // Reference of latest patch set.
+	Commit  string `json:"commit,omitempty"`
+	Updated string `json:"updated,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// ReviewQuery holds conditions to query code reviews.
+type ReviewQuery struct {
+	URL      string // Root URL of review server, e.g.: https://review.example.com
+	Project  string
+	Owner    string
+	Topic    string
+	ChangeID string // Change-Id in commit message.
+	Query    string // Raw query passed to review server.
+	Status   string // Status of code review, default is open.
+	Limit    int
+}
+
+// ReviewURL holds fields parsed from web URL of a code review.
+type ReviewURL struct {
+	URL     string // Root URL of review server.
+	Host    string
+	Project string
+	ID      string
+	Patch   string
+}
+
+// ParseReviewURL parses web URL of code review of Gerrit or AGit, and
+// returns nil if address is not a review URL.
+func ParseReviewURL(address string) *ReviewURL {
+	for _, re := range []*regexp.Regexp{reGerritChangeURL, reAGitReviewURL} {
+		m := re.FindStringSubmatch(address)
+		if m == nil {
+			continue
+		}
+		return &ReviewURL{
+			URL:     m[1],
+			Host:    m[2],
+			Project: strings.TrimSuffix(m[3], ".git"),
+			ID:      m[4],
+			Patch:   m[5],
+		}
+	}
+	return nil
+}
+
+// ReviewQuerier is an optional interface of proto helper to query code
+// reviews from the review server.
+type ReviewQuerier interface {
+	ListReviews(q *ReviewQuery) ([]ReviewInfo, error)
+	GetReview(q *ReviewQuery, id string) (*ReviewInfo, error)
+}
+
+// NewReviewQuerier returns review querier of proto helper, or error if
+// proto helper cannot query code reviews.
+func NewReviewQuerier(proto ProtoHelper) (ReviewQuerier, error) {
+	if proto == nil {
+		return nil, fmt.Errorf("no proto helper")
+	}
+	if querier, ok := proto.(ReviewQuerier); ok {
+		return querier, nil
+	}
+	protoType := proto.GetType()
+	if protoType == "" {
+		protoType = "remote"
+	}
+	return nil, fmt.Errorf("query code reviews is not supported by %s", protoType)
+}
+
+// httpGetJSON sends GET request to address, and decodes JSON response
+// into v.
+func httpGetJSON(address string, v interface{}) error {
+	// Mock code review query API
+	if config.GetMockReviewQueryResponse() != "" {
+		gock.New(address).
+			Reply(http.StatusOK).
+			BodyString(config.GetMockReviewQueryResponse())
+	}
+
+	log.Debugf("query code reviews from API: %s", address)
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return fmt.Errorf("bad request to '%s': %s", address, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := HTTPDo(getHTTPClient(), req)
+	if err != nil {
+		return fmt.Errorf("bad request to '%s': %s", address, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("404: not found '%s'", address)
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%d: bad response of '%s'", resp.StatusCode, address)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("fail to read response of '%s': %s", address, err)
+	}
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte(gerritXSSIPrefix))
+	err = json.Unmarshal(data, v)
+	if err != nil {
+		return fmt.Errorf("bad response of '%s': %s", address, err)
+	}
+	return nil
+}
+
+// reviewQueryURL returns root URL of review server without trailing slash.
+func reviewQueryURL(q *ReviewQuery) (string, error) {
+	if q == nil || q.URL == "" {
+		return "", fmt.Errorf("no URL of review server to query")
+	}
+	if !strings.HasPrefix(q.URL, "http://") && !strings.HasPrefix(q.URL, "https://") {
+		return "", fmt.Errorf("cannot query code reviews from '%s', HTTP is required", q.URL)
+	}
+	return strings.TrimSuffix(q.URL, "/"), nil
+}
@@ -0,0 +1,262 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const gerritChangesResponse = `)]}'
+[
+  {
+    "project": "test/repo",
+    "branch": "master",
+    "topic": "my-topic",
+    "subject": "Fix bug",
+    "status": "NEW",
+    "updated": "2021-01-02 03:04:05.000000000",
+    "_number": 12345,
+    "owner": {"name": "Jane", "email": "jane@example.com", "username": "jane"},
+    "current_revision": "1234567890123456789012345678901234567890",
+    "revisions": {
+      "1234567890123456789012345678901234567890": {
+        "_number": 3,
+        "ref": "refs/changes/45/12345/3"
+      }
+    }
+  },
+  {
+    "project": "test/repo",
+    "branch": "maint",
+    "subject": "Old change",
+    "status": "MERGED",
+    "updated": "2021-01-01 00:00:00.000000000",
+    "_number": 100,
+    "owner": {"name": "Joe"}
+  }
+]
+`
+
+const gerritChangeResponse = `)]}'
+{
+  "project": "test/repo",
+  "branch": "master",
+  "subject": "Fix bug",
+  "status": "ABANDONED",
+  "updated": "2021-01-02 03:04:05.000000000",
+  "_number": 12345,
+  "owner": {"username": "jane"},
+  "current_revision": "1234567890123456789012345678901234567890",
+  "revisions": {
+    "1234567890123456789012345678901234567890": {"_number": 2}
+  }
+}
+`
+
+func TestGerritReviewQuery(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		request *http.Request
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		switch r.URL.Path {
+		case "/changes/":
+			w.Write([]byte(gerritChangesResponse))
+		case "/changes/test%2Frepo~12345", "/changes/test/repo~12345":
+			w.Write([]byte(gerritChangeResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	querier, err := NewReviewQuerier(NewGerritProtoHelper(&SSHInfo{}))
+	assert.Nil(err)
+
+	reviews, err := querier.ListReviews(&ReviewQuery{
+		URL:     ts.URL + "/",
+		Project: "test/repo",
+		Owner:   "jane@example.com",
+		Limit:   10,
+	})
+	assert.Nil(err)
+	assert.Equal("status:open project:test/repo owner:jane@example.com", request.URL.Query().Get("q"))
+	assert.Equal("10", request.URL.Query().Get("n"))
+	assert.Equal([]string{"CURRENT_REVISION", "DETAILED_ACCOUNTS"}, request.URL.Query()["o"])
+	assert.Equal([]ReviewInfo{
+		{
+			ID:      "12345",
+			Project: "test/repo",
+			Branch:  "master",
+			Topic:   "my-topic",
+			Subject: "Fix bug",
+			Owner:   "jane@example.com",
+			Status:  ReviewStatusOpen,
+			Patch:   "3",
+			Ref:     "refs/changes/45/12345/3",
+			Commit:  "1234567890123456789012345678901234567890",
+			Updated: "2021-01-02 03:04:05",
+			URL:     ts.URL + "/c/test/repo/+/12345",
+		},
+		{
+			ID:      "100",
+			Project: "test/repo",
+			Branch:  "maint",
+			Subject: "Old change",
+			Owner:   "Joe",
+			Status:  ReviewStatusMerged,
+			Updated: "2021-01-01 00:00:00",
+			URL:     ts.URL + "/c/test/repo/+/100",
+		},
+	}, reviews)
+
+	_, err = querier.ListReviews(&ReviewQuery{URL: ts.URL, Status: ReviewStatusAll})
+	assert.Nil(err)
+	assert.Equal("", request.URL.Query().Get("q"))
+
+	_, err = querier.ListReviews(&ReviewQuery{URL: ts.URL, Topic: "my-topic", ChangeID: "I0123"})
+	assert.Nil(err)
+	assert.Equal("status:open topic:my-topic change:I0123", request.URL.Query().Get("q"))
+
+	_, err = querier.ListReviews(&ReviewQuery{URL: ts.URL, Query: "label:Verified+1"})
+	assert.Nil(err)
+	assert.Equal("status:open label:Verified+1", request.URL.Query().Get("q"))
+
+	_, err = querier.ListReviews(&ReviewQuery{URL: ts.URL, Status: "bad"})
+	assert.Equal("unknown status of code review: bad", err.Error())
+
+	review, err := querier.GetReview(&ReviewQuery{URL: ts.URL, Project: "test/repo"}, "12345")
+	assert.Nil(err)
+	assert.Equal(&ReviewInfo{
+		ID:      "12345",
+		Project: "test/repo",
+		Branch:  "master",
+		Subject: "Fix bug",
+		Owner:   "jane",
+		Status:  ReviewStatusClosed,
+		Patch:   "2",
+		Ref:     "refs/changes/45/12345/2",
+		Commit:  "1234567890123456789012345678901234567890",
+		Updated: "2021-01-02 03:04:05",
+		URL:     ts.URL + "/c/test/repo/+/12345",
+	}, review)
+
+	_, err = querier.GetReview(&ReviewQuery{URL: ts.URL}, "404")
+	assert.Equal("404: not found '"+ts.URL+"/changes/404?o=CURRENT_REVISION&o=DETAILED_ACCOUNTS'", err.Error())
+
+	_, err = querier.GetReview(&ReviewQuery{URL: "ssh://example.com"}, "1")
+	assert.Equal("cannot query code reviews from 'ssh://example.com', HTTP is required", err.Error())
+}
+
+func TestAGitReviewQuery(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		request *http.Request
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		switch r.URL.Path {
+		case "/api/v1/reviews":
+			w.Write([]byte(`[{"id": 12, "project": "test/repo", "target_branch": "refs/heads/master",
+				"title": "Fix bug", "state": "open", "author": "jane", "patchset": 2,
+				"commit": "1234567", "updated_at": "2021-01-02 03:04:05",
+				"web_url": "https://example.com/test/repo/change/12"}]`))
+		case "/api/v1/reviews/12":
+			w.Write([]byte(`{"id": 12, "project": "test/repo", "target_branch": "master",
+				"title": "Fix bug", "state": "MERGED", "patchset": 3}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	querier, err := NewReviewQuerier(NewAGitProtoHelper(&SSHInfo{ProtoVersion: 3}))
+	assert.Nil(err)
+
+	reviews, err := querier.ListReviews(&ReviewQuery{
+		URL:     ts.URL,
+		Project: "test/repo",
+		Owner:   "jane",
+	})
+	assert.Nil(err)
+	assert.Equal("owner=jane&project=test%2Frepo&status=open", request.URL.RawQuery)
+	assert.Equal([]ReviewInfo{
+		{
+			ID:      "12",
+			Project: "test/repo",
+			Branch:  "master",
+			Subject: "Fix bug",
+			Owner:   "jane",
+			Status:  ReviewStatusOpen,
+			Patch:   "2",
+			Ref:     "refs/changes/12/2",
+			Commit:  "1234567",
+			Updated: "2021-01-02 03:04:05",
+			URL:     "https://example.com/test/repo/change/12",
+		},
+	}, reviews)
+
+	review, err := querier.GetReview(&ReviewQuery{URL: ts.URL, Project: "test/repo"}, "12")
+	assert.Nil(err)
+	assert.Equal("project=test%2Frepo", request.URL.RawQuery)
+
+	_, err = querier.ListReviews(&ReviewQuery{URL: ts.URL, Topic: "my-topic", ChangeID: "I0123"})
+	assert.Nil(err)
+	assert.Equal("change_id=I0123&status=open&topic=my-topic", request.URL.RawQuery)
+	assert.Equal(ReviewStatusMerged, review.Status)
+	assert.Equal("refs/changes/12/3", review.Ref)
+
+	_, err = querier.GetReview(&ReviewQuery{URL: ts.URL}, "13")
+	assert.Equal("500: bad response of '"+ts.URL+"/api/v1/reviews/13'", err.Error())
+
+	_, err = querier.GetReview(&ReviewQuery{URL: ts.URL}, "abc")
+	assert.NotNil(err)
+}
+
+func TestNewReviewQuerier(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewReviewQuerier(NewGitLabProtoHelper(&SSHInfo{}))
+	assert.Equal("query code reviews is not supported by gitlab", err.Error())
+	_, err = NewReviewQuerier(NewDefaultProtoHelper(&SSHInfo{}))
+	assert.Equal("query code reviews is not supported by remote", err.Error())
+}
+
+func TestParseReviewURL(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(&ReviewURL{
+		URL:     "https://gerrit.example.com",
+		Host:    "gerrit.example.com",
+		Project: "group/repo",
+		ID:      "12345",
+		Patch:   "2",
+	}, ParseReviewURL("https://gerrit.example.com/c/group/repo/+/12345/2"))
+	assert.Equal(&ReviewURL{
+		URL:     "https://example.com/r",
+		Host:    "example.com",
+		Project: "repo",
+		ID:      "12345",
+	}, ParseReviewURL("https://example.com/r/#/c/repo/+/12345/"))
+	assert.Equal(&ReviewURL{
+		URL:     "http://example.com:8080",
+		Host:    "example.com:8080",
+		Project: "group/repo",
+		ID:      "123",
+	}, ParseReviewURL("http://example.com:8080/group/repo.git/merge_request/123"))
+	assert.Equal(&ReviewURL{
+		URL:     "https://example.com",
+		Host:    "example.com",
+		Project: "group/repo",
+		ID:      "12",
+		Patch:   "3",
+	}, ParseReviewURL("https://example.com/group/repo/-/merge_requests/12/3"))
+	assert.Nil(ParseReviewURL("https://example.com/group/repo"))
+	assert.Nil(ParseReviewURL("12345/1"))
+	assert.Nil(ParseReviewURL("ssh://example.com/c/repo/+/12345"))
+}
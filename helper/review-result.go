@@ -0,0 +1,59 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// reReviewURL matches URL of code review ends with review ID, such as:
+	// "https://gerrit.example.com/c/project/+/12345" (Gerrit), or
+	// "https://example.com/project/merge_request/123" (AGit).
+	reReviewURL = regexp.MustCompile(`(https?://\S*/([0-9]+))/?(?:\s|$)`)
+
+	// reGitLabReviewURL matches URL of merge request of GitLab.
+	reGitLabReviewURL = regexp.MustCompile(`(https?://\S*/merge_requests/([0-9]+))/?(?:\s|$)`)
+)
+
+// ReviewResult holds code review created or updated by git push.
+type ReviewResult struct {
+	ID  string `json:"id"`
+	URL string `json:"url,omitempty"`
+}
+
+// parseReviewResults scans "remote:" lines of git push output for review
+// URLs using pattern, which has two groups for URL and ID.
+func parseReviewResults(output []byte, pattern *regexp.Regexp) []ReviewResult {
+	var (
+		results []ReviewResult
+		found   = make(map[string]bool)
+	)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if !strings.HasPrefix(line, "remote:") {
+			continue
+		}
+		m := pattern.FindStringSubmatch(line)
+		if m == nil || found[m[2]] {
+			continue
+		}
+		found[m[2]] = true
+		results = append(results, ReviewResult{ID: m[2], URL: m[1]})
+	}
+	return results
+}
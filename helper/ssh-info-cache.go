@@ -0,0 +1,175 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+)
+
+// SSHInfoCacheEntry is an entry of ssh_info cache file.
+type SSHInfoCacheEntry struct {
+	Key     string   `json:"key"`
+	SSHInfo *SSHInfo `json:"sshinfo,omitempty"`
+	Error   string   `json:"error,omitempty"` // Negative cache.
+	Expire  string   `json:"expire,omitempty"`
+	Expired bool     `json:"expired"`
+}
+
+// cacheEntry reads cache entry of key from cache file, and returns nil if
+// not found.
+func (v SSHInfoQuery) cacheEntry(key string) *SSHInfoCacheEntry {
+	if v.cfg == nil {
+		return nil
+	}
+	entry := SSHInfoCacheEntry{
+		Key:     key,
+		Error:   v.cfg.Get(fmt.Sprintf(config.CfgManifestRemoteError, key)),
+		Expire:  v.cfg.Get(fmt.Sprintf(config.CfgManifestRemoteExpire, key)),
+		Expired: true,
+	}
+	data := v.cfg.Get(fmt.Sprintf(config.CfgManifestRemoteSSHInfo, key))
+	if data == "" && entry.Error == "" {
+		return nil
+	}
+	if data != "" {
+		sshInfo := SSHInfo{}
+		err := json.Unmarshal([]byte(data), &sshInfo)
+		if err == nil && sshInfo.ProtoType != "" {
+			entry.SSHInfo = &sshInfo
+		} else {
+			log.Warnf("fail to parse ssh_info cache from '%s': '%s'", v.CacheFile, data)
+		}
+	}
+	if entry.Expire != "" {
+		expireTm, err := time.ParseInLocation(expireTimeLayout, entry.Expire, time.Local)
+		if err == nil && expireTm.After(time.Now()) {
+			entry.Expired = false
+		}
+	}
+	return &entry
+}
+
+// CacheEntries returns all entries in cache file, sorted by key.
+func (v SSHInfoQuery) CacheEntries() []SSHInfoCacheEntry {
+	var (
+		entries []SSHInfoCacheEntry
+		found   = make(map[string]bool)
+	)
+
+	if v.cfg == nil {
+		return nil
+	}
+	for _, k := range v.cfg.Keys() {
+		if !strings.HasPrefix(k, "manifest.remote.") {
+			continue
+		}
+		k = strings.TrimPrefix(k, "manifest.remote.")
+		pos := strings.LastIndex(k, ".")
+		if pos < 0 {
+			continue
+		}
+		key := k[:pos]
+		if found[key] {
+			continue
+		}
+		found[key] = true
+		if entry := v.cacheEntry(key); entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// CacheEntry returns cache entry of address, and returns nil if not found.
+func (v SSHInfoQuery) CacheEntry(address string) *SSHInfoCacheEntry {
+	key := urlToKey(address)
+	if key == "" {
+		return nil
+	}
+	return v.cacheEntry(key)
+}
+
+// ClearCache removes cache entries of addresses, or removes the cache
+// file if no address is given. Returns number of removed entries.
+func (v SSHInfoQuery) ClearCache(addresses ...string) (int, error) {
+	if v.CacheFile == "" || v.cfg == nil {
+		return 0, nil
+	}
+	if len(addresses) == 0 {
+		n := len(v.CacheEntries())
+		err := os.Remove(v.CacheFile)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		internalCache.Range(func(key, value interface{}) bool {
+			internalCache.Delete(key)
+			return true
+		})
+		return n, nil
+	}
+
+	n := 0
+	for _, address := range addresses {
+		key := urlToKey(address)
+		if key == "" {
+			return n, fmt.Errorf("bad address for review '%s'", address)
+		}
+		internalCache.Delete(key)
+		if v.cacheEntry(key) == nil {
+			continue
+		}
+		v.cfg.Unset(fmt.Sprintf(config.CfgManifestRemoteSSHInfo, key))
+		v.cfg.Unset(fmt.Sprintf(config.CfgManifestRemoteError, key))
+		v.cfg.Unset(fmt.Sprintf(config.CfgManifestRemoteExpire, key))
+		n++
+	}
+	if n > 0 {
+		return n, v.cfg.Save(v.CacheFile)
+	}
+	return n, nil
+}
+
+// RefreshCache queries ssh_info of address without cache, and saves
+// result into cache file.
+func (v SSHInfoQuery) RefreshCache(address string) (*SSHInfo, error) {
+	key := urlToKey(address)
+	if key == "" {
+		return nil, fmt.Errorf("bad address for review '%s'", address)
+	}
+
+	// Result in internal cache is queried by this process already.
+	if cache, ok := internalCache.Load(key); ok {
+		switch cache := cache.(type) {
+		case error:
+			v.saveCache(key, nil, cache)
+			return nil, cache
+		case *SSHInfo:
+			v.saveCache(key, cache, nil)
+			return cache, nil
+		}
+	}
+	return v.GetSSHInfo(address, false)
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHInfoCache(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		cacheFile = filepath.Join(t.TempDir(), "sshinfo.cache")
+	)
+
+	defer os.Unsetenv("REPO_HOST_PORT_INFO")
+	os.Setenv("REPO_HOST_PORT_INFO", "ssh.example.com 29418")
+
+	query := NewSSHInfoQuery(cacheFile)
+	sshInfo, err := query.GetSSHInfo("https://cache1.example.com", true)
+	assert.Nil(err)
+	assert.Equal(ProtoTypeGerrit, sshInfo.ProtoType)
+
+	query = NewSSHInfoQuery(cacheFile)
+	entries := query.CacheEntries()
+	if assert.Equal(1, len(entries)) {
+		assert.Equal("https://cache1.example.com", entries[0].Key)
+		assert.Equal("ssh.example.com", entries[0].SSHInfo.Host)
+		assert.False(entries[0].Expired)
+	}
+	assert.NotNil(query.CacheEntry("https://cache1.example.com/"))
+	assert.Nil(query.CacheEntry("https://unknown.example.com"))
+
+	// Negative cache is disabled by default.
+	os.Unsetenv("REPO_HOST_PORT_INFO")
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	_, err = query.GetSSHInfo(notFound.URL, true)
+	assert.NotNil(err)
+	assert.Equal(1, len(NewSSHInfoQuery(cacheFile).CacheEntries()))
+	internalCache.Delete(urlToKey(notFound.URL))
+
+	// Only cache definitive result of server without ssh_info API.
+	config.GitDefaultConfig.Set(config.CfgRepoSSHInfoNegTTL, "10m")
+	defer config.GitDefaultConfig.Unset(config.CfgRepoSSHInfoNegTTL)
+	serverError := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer serverError.Close()
+	_, err = query.GetSSHInfo(serverError.URL, true)
+	assert.NotNil(err)
+	assert.Nil(NewSSHInfoQuery(cacheFile).CacheEntry(serverError.URL))
+
+	_, err = query.GetSSHInfo(notFound.URL, true)
+	assert.NotNil(err)
+	query = NewSSHInfoQuery(cacheFile)
+	entry := query.CacheEntry(notFound.URL)
+	if assert.NotNil(entry) {
+		assert.Nil(entry.SSHInfo)
+		assert.Equal(err.Error(), entry.Error)
+	}
+
+	// Refresh replaces negative cache.
+	internalCache.Delete(urlToKey(notFound.URL))
+	os.Setenv("REPO_HOST_PORT_INFO", "ssh.example.com 29418")
+	_, err = query.RefreshCache(notFound.URL)
+	assert.Nil(err)
+	entry = NewSSHInfoQuery(cacheFile).CacheEntry(notFound.URL)
+	if assert.NotNil(entry) {
+		assert.NotNil(entry.SSHInfo)
+		assert.Equal("", entry.Error)
+	}
+
+	n, err := query.ClearCache("https://cache1.example.com", "https://unknown.example.com")
+	assert.Nil(err)
+	assert.Equal(1, n)
+	assert.Equal(1, len(NewSSHInfoQuery(cacheFile).CacheEntries()))
+
+	n, err = NewSSHInfoQuery(cacheFile).ClearCache()
+	assert.Nil(err)
+	assert.Equal(1, n)
+	_, err = os.Stat(cacheFile)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestSSHInfoCacheTTL(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		cacheFile = filepath.Join(t.TempDir(), "sshinfo.cache")
+	)
+
+	defer os.Unsetenv("REPO_HOST_PORT_INFO")
+	os.Setenv("REPO_HOST_PORT_INFO", "ssh.example.com 29418")
+
+	config.GitDefaultConfig.Set(config.CfgRepoSSHInfoTTL, "-1s")
+	defer config.GitDefaultConfig.Unset(config.CfgRepoSSHInfoTTL)
+	query := NewSSHInfoQuery(cacheFile)
+	_, err := query.GetSSHInfo("https://ttl1.example.com", true)
+	assert.Nil(err)
+	assert.Equal(0, len(NewSSHInfoQuery(cacheFile).CacheEntries()))
+
+	config.GitDefaultConfig.Set(config.CfgRepoSSHInfoTTL, "1h")
+	_, err = query.GetSSHInfo("https://ttl2.example.com", true)
+	assert.Nil(err)
+	entry := NewSSHInfoQuery(cacheFile).CacheEntry("https://ttl2.example.com")
+	if assert.NotNil(entry) {
+		assert.False(entry.Expired)
+	}
+}
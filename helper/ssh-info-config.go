@@ -0,0 +1,94 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+)
+
+// sshInfoConfigURL returns URL prefix in "repo.<url>.*" of git config,
+// which has the longest match with address.
+func sshInfoConfigURL(address string) string {
+	return urlConfigPrefix("repo", address, func(prefix string) bool {
+		return config.GitDefaultConfig.Get(fmt.Sprintf(config.CfgRepoURLSSHInfo, prefix)) != "" ||
+			config.GitDefaultConfig.Get(fmt.Sprintf(config.CfgRepoURLType, prefix)) != ""
+	})
+}
+
+// SSHInfoFromConfig returns static ssh_info of address defined in git
+// config, such as:
+//
+//	[repo "https://example.com"]
+//		sshinfo = {"host":"ssh.example.com","port":22,"type":"agit"}
+//		type = agit
+//		version = 2
+//		reviewRef = refs/merge-requests/{id}/head
+//
+// The URL with the longest matched prefix wins, and is returned as the
+// second value. Returns empty URL if not defined in git config.
+func SSHInfoFromConfig(address string) (*SSHInfo, string, error) {
+	var (
+		sshInfo = &SSHInfo{}
+		err     error
+		cfg     = config.GitDefaultConfig
+	)
+
+	prefix := sshInfoConfigURL(address)
+	if prefix == "" {
+		return nil, "", nil
+	}
+
+	if data := cfg.Get(fmt.Sprintf(config.CfgRepoURLSSHInfo, prefix)); data != "" {
+		sshInfo, err = sshInfoFromString(data)
+		if err != nil {
+			return nil, prefix, fmt.Errorf("bad ssh_info in git config of 'repo.%s': %s", prefix, err)
+		}
+	}
+	if protoType := cfg.Get(fmt.Sprintf(config.CfgRepoURLType, prefix)); protoType != "" {
+		sshInfo.ProtoType = protoType
+	}
+	if version := cfg.Get(fmt.Sprintf(config.CfgRepoURLVersion, prefix)); version != "" {
+		sshInfo.ProtoVersion, err = strconv.Atoi(version)
+		if err != nil {
+			return nil, prefix, fmt.Errorf("bad version in git config of 'repo.%s': %s", prefix, version)
+		}
+	}
+	if reviewRef := cfg.Get(fmt.Sprintf(config.CfgRepoURLReviewRef, prefix)); reviewRef != "" {
+		sshInfo.ReviewRefPattern = reviewRef
+	}
+	if sshInfo.ProtoType == "" {
+		return nil, prefix, fmt.Errorf("no type of ssh_info in git config of 'repo.%s'", prefix)
+	}
+	return sshInfo, prefix, nil
+}
+
+// SSHInfoSource returns where ssh_info of address comes from.
+func SSHInfoSource(address string) string {
+	if os.Getenv("REPO_HOST_PORT_INFO") != "" {
+		return "environment REPO_HOST_PORT_INFO"
+	}
+	if _, prefix, _ := SSHInfoFromConfig(strings.TrimPrefix(address, "persistent-")); prefix != "" {
+		return fmt.Sprintf("git config 'repo.%s'", prefix)
+	}
+	if os.Getenv("REPO_IGNORE_SSH_INFO") != "" {
+		return "environment REPO_IGNORE_SSH_INFO"
+	}
+	return "ssh_info API"
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHInfoFromConfig(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		cfg    = config.GitDefaultConfig
+		keys   = []string{
+			"repo.https://config.example.com.sshinfo",
+			"repo.https://config.example.com/team.type",
+			"repo.https://config.example.com/team.version",
+			"repo.https://config.example.com/team.reviewRef",
+			"repo.https://bad.example.com.type",
+			"repo.https://bad.example.com.version",
+		}
+	)
+
+	defer func() {
+		for _, key := range keys {
+			cfg.Unset(key)
+		}
+	}()
+	cfg.Set(keys[0], `{"host":"ssh.example.com","port":29418,"type":"gerrit"}`)
+	cfg.Set(keys[1], "agit")
+	cfg.Set(keys[2], "2")
+	cfg.Set(keys[3], "refs/merge-requests/{id}/head")
+	cfg.Set(keys[4], "agit")
+	cfg.Set(keys[5], "bad")
+
+	sshInfo, prefix, err := SSHInfoFromConfig("https://config.example.com/app.git")
+	assert.Nil(err)
+	assert.Equal("https://config.example.com", prefix)
+	assert.Equal("ssh.example.com", sshInfo.Host)
+	assert.Equal(29418, sshInfo.Port)
+	assert.Equal(ProtoTypeGerrit, sshInfo.ProtoType)
+
+	// Longest prefix wins.
+	sshInfo, prefix, err = SSHInfoFromConfig("https://config.example.com/team/app.git")
+	assert.Nil(err)
+	assert.Equal("https://config.example.com/team", prefix)
+	assert.Equal("", sshInfo.Host)
+	assert.Equal(ProtoTypeAGit, sshInfo.ProtoType)
+	assert.Equal(2, sshInfo.ProtoVersion)
+	assert.Equal("refs/merge-requests/{id}/head", sshInfo.ReviewRefPattern)
+
+	// Match at boundary of path.
+	_, prefix, _ = SSHInfoFromConfig("https://config.example.com.cn/app.git")
+	assert.Equal("", prefix)
+	_, prefix, _ = SSHInfoFromConfig("https://config.example.com/teams")
+	assert.Equal("https://config.example.com", prefix)
+
+	_, prefix, err = SSHInfoFromConfig("https://bad.example.com")
+	assert.Equal("https://bad.example.com", prefix)
+	assert.Equal("bad version in git config of 'repo.https://bad.example.com': bad", err.Error())
+
+	// Git config overrides ssh_info API, and is not saved in cache file.
+	os.Unsetenv("REPO_HOST_PORT_INFO")
+	cacheFile := filepath.Join(t.TempDir(), "sshinfo.cache")
+	query := NewSSHInfoQuery(cacheFile)
+	sshInfo, err = query.GetSSHInfo("https://config.example.com/team/app.git", true)
+	assert.Nil(err)
+	assert.Equal(ProtoTypeAGit, sshInfo.ProtoType)
+	assert.Equal(0, len(NewSSHInfoQuery(cacheFile).CacheEntries()))
+	assert.Equal("git config 'repo.https://config.example.com/team'",
+		SSHInfoSource("https://config.example.com/team/app.git"))
+	assert.Equal("ssh_info API", SSHInfoSource("https://other.example.com"))
+}
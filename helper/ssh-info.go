@@ -103,6 +103,18 @@ func (v SSHInfo) Validate() error {
 	return nil
 }
 
+// sshInfoNotFoundError indicates server has no ssh_info API, such as 404
+// response or response not in format of ssh_info. It is a definitive
+// result, and can be saved in negative cache, while errors of network or
+// authentication are not.
+type sshInfoNotFoundError struct {
+	err error
+}
+
+func (e *sshInfoNotFoundError) Error() string {
+	return e.err.Error()
+}
+
 // SSHInfoQuery wraps cache to accelerate query of ssh_info API.
 type SSHInfoQuery struct {
 	CacheFile string
@@ -122,6 +134,16 @@ func (v SSHInfoQuery) GetSSHInfo(address string, useCache bool) (*SSHInfo, error
 		return nil, fmt.Errorf("bad address for review '%s'", address)
 	}
 
+	// Static ssh_info in git config is not cached, and takes effect at
+	// once. Environment REPO_HOST_PORT_INFO has higher priority.
+	if os.Getenv("REPO_HOST_PORT_INFO") == "" {
+		sshInfo, prefix, err := SSHInfoFromConfig(strings.TrimPrefix(address, "persistent-"))
+		if prefix != "" {
+			log.Debugf("get ssh_info from git config of 'repo.%s'", prefix)
+			return sshInfo, err
+		}
+	}
+
 	// Try internal cache
 	if cache, ok := internalCache.Load(key); ok {
 		switch cache.(type) {
@@ -136,27 +158,18 @@ func (v SSHInfoQuery) GetSSHInfo(address string, useCache bool) (*SSHInfo, error
 
 	// Try cache
 	if v.CacheFile != "" && v.cfg != nil && useCache {
-		data := v.cfg.Get(fmt.Sprintf(config.CfgManifestRemoteSSHInfo, key))
-		if data != "" {
-			expired := true
-			expireStr := v.cfg.Get(fmt.Sprintf(config.CfgManifestRemoteExpire, key))
-			if expireStr != "" {
-				expireTm, err := time.Parse(expireTimeLayout, expireStr)
-				if err == nil && expireTm.After(time.Now()) {
-					expired = false
-				}
+		entry := v.cacheEntry(key)
+		if entry != nil && !entry.Expired {
+			if entry.Error != "" {
+				log.Debugf("get ssh_info error from cache '%s': '%s'", v.CacheFile, entry.Error)
+				return nil, errors.New(entry.Error)
 			}
-			if !expired {
-				sshInfo := SSHInfo{}
-				err = json.Unmarshal([]byte(data), &sshInfo)
-				if err == nil && sshInfo.ProtoType != "" {
-					log.Debugf("get ssh_info cache from '%s': '%s'", v.CacheFile, string(data))
-					return &sshInfo, nil
-				}
-				log.Warnf("fail to parse ssh_info cache from '%s': '%s'", v.CacheFile, data)
-			} else {
-				log.Debugf("cache of ssh_info in '%s' is expired", v.CacheFile)
+			if entry.SSHInfo != nil {
+				log.Debugf("get ssh_info cache from '%s': '%s'", v.CacheFile, entry.SSHInfo.ToJSON())
+				return entry.SSHInfo, nil
 			}
+		} else if entry != nil {
+			log.Debugf("cache of ssh_info in '%s' is expired", v.CacheFile)
 		}
 	}
 
@@ -165,6 +178,10 @@ func (v SSHInfoQuery) GetSSHInfo(address string, useCache bool) (*SSHInfo, error
 	if err != nil {
 		// Update internal cache
 		internalCache.Store(key, err)
+		var notFound *sshInfoNotFoundError
+		if errors.As(err, &notFound) {
+			v.saveCache(key, nil, err)
+		}
 		return nil, err
 	}
 	// Update internal cache
@@ -172,17 +189,43 @@ func (v SSHInfoQuery) GetSSHInfo(address string, useCache bool) (*SSHInfo, error
 	log.Debugf("query ssh_info successfully: %#v", sshInfo)
 
 	// Update Cache
-	if v.CacheFile != "" && v.cfg != nil {
-		path.SafeCreateParentDir(v.CacheFile)
-		data := sshInfo.ToJSON()
-		expireTime := time.Now().Add(time.Second * sshInfoCacheDefaultExpire).Format(expireTimeLayout)
+	v.saveCache(key, sshInfo, nil)
+	return sshInfo, nil
+}
+
+// saveCache saves ssh_info or error of key into cache file. Error is only
+// saved if negative cache is enabled by "repo.sshInfoNegativeTTL".
+func (v SSHInfoQuery) saveCache(key string, sshInfo *SSHInfo, err error) {
+	var (
+		ttl  time.Duration
+		data string
+	)
+
+	if v.CacheFile == "" || v.cfg == nil {
+		return
+	}
+	if err != nil {
+		ttl = config.GetDuration(config.CfgRepoSSHInfoNegTTL, 0)
+		if ttl <= 0 {
+			return
+		}
+		data = err.Error()
+		v.cfg.Unset(fmt.Sprintf(config.CfgManifestRemoteSSHInfo, key))
+		v.cfg.Set(fmt.Sprintf(config.CfgManifestRemoteError, key), data)
+	} else {
+		ttl = config.GetDuration(config.CfgRepoSSHInfoTTL, sshInfoCacheDefaultExpire*time.Second)
+		if ttl <= 0 {
+			return
+		}
+		data = sshInfo.ToJSON()
+		v.cfg.Unset(fmt.Sprintf(config.CfgManifestRemoteError, key))
 		v.cfg.Set(fmt.Sprintf(config.CfgManifestRemoteSSHInfo, key), data)
-		v.cfg.Set(fmt.Sprintf(config.CfgManifestRemoteExpire, key), expireTime)
-		v.cfg.Save(v.CacheFile)
-		log.Debugf("save cache file '%s', expire at '%s', data: '%s'", v.CacheFile, expireTime, data)
 	}
-
-	return sshInfo, nil
+	path.SafeCreateParentDir(v.CacheFile)
+	expireTime := time.Now().Add(ttl).Format(expireTimeLayout)
+	v.cfg.Set(fmt.Sprintf(config.CfgManifestRemoteExpire, key), expireTime)
+	v.cfg.Save(v.CacheFile)
+	log.Debugf("save cache file '%s', expire at '%s', data: '%s'", v.CacheFile, expireTime, data)
 }
 
 // NewSSHInfoQuery creates new query object. file is name of the cache.
@@ -275,7 +318,7 @@ func sshInfoFromAPI(url *config.GitURL) (*SSHInfo, error) {
 
 	client := getHTTPClient()
 
-	resp, err := client.Do(req)
+	resp, err := HTTPDo(client, req)
 	if err != nil {
 		return nil, fmt.Errorf("bad ssh_info request to '%s': %s", infoURL, err)
 	}
@@ -283,9 +326,13 @@ func sshInfoFromAPI(url *config.GitURL) (*SSHInfo, error) {
 
 	// Successful status code maybe 200, 201.
 	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return nil, fmt.Errorf("%d: bad ssh_info response of '%s'",
+		err = fmt.Errorf("%d: bad ssh_info response of '%s'",
 			resp.StatusCode,
 			infoURL)
+		if resp.StatusCode == http.StatusNotFound {
+			err = &sshInfoNotFoundError{err}
+		}
+		return nil, err
 	}
 
 	reader := bufio.NewReader(resp.Body)
@@ -300,9 +347,9 @@ func sshInfoFromAPI(url *config.GitURL) (*SSHInfo, error) {
 
 	sshInfo, err := sshInfoFromString(buf.String())
 	if err != nil {
-		return nil, fmt.Errorf("fail to run ssh_info API on %s: %s",
+		return nil, &sshInfoNotFoundError{fmt.Errorf("fail to run ssh_info API on %s: %s",
 			url.GetRootURL(),
-			err)
+			err)}
 	}
 	return sshInfo, nil
 }
@@ -441,8 +488,10 @@ func getHTTPClient() *http.Client {
 
 	httpClient = &http.Client{Transport: tr}
 
-	// Mock ssh_info API
-	if config.GetMockSSHInfoResponse() != "" || config.GetMockSSHInfoStatus() != 0 {
+	// Mock ssh_info API and code review query API
+	if config.GetMockSSHInfoResponse() != "" ||
+		config.GetMockSSHInfoStatus() != 0 ||
+		config.GetMockReviewQueryResponse() != "" {
 		gock.InterceptClient(httpClient)
 	}
 
@@ -0,0 +1,127 @@
+// Copyright © 2019 Alibaba Co. Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/path"
+)
+
+// LocalManifestFile returns full path of the local manifest file with name.
+func LocalManifestFile(repoDir, name string) string {
+	if !strings.HasSuffix(name, ".xml") {
+		name += ".xml"
+	}
+	return filepath.Join(repoDir, config.LocalManifests, name)
+}
+
+// LoadLocalManifest reads local manifest file for edit. Returns an empty
+// manifest if file does not exist.
+//
+// The returned manifest is not fixed by CheckAndFixup(), so the content will
+// not be changed when saving back.
+func LoadLocalManifest(file string) (*Manifest, error) {
+	if _, err := os.Stat(file); err != nil {
+		if os.IsNotExist(err) {
+			return &Manifest{SourceFile: file}, nil
+		}
+		return nil, err
+	}
+	m, err := unmarshalFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m.SourceFile = file
+	return m, nil
+}
+
+// SaveLocalManifest saves local manifest to its source file.
+func SaveLocalManifest(m *Manifest) error {
+	if m.SourceFile == "" {
+		return fmt.Errorf("no file to save local manifest")
+	}
+	data, err := Marshal(m)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	path.SafeCreateParentDir(m.SourceFile)
+	lockFile := m.SourceFile + ".lock"
+	err = ioutil.WriteFile(lockFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(lockFile, m.SourceFile)
+}
+
+// LoadWithLocalManifest loads and merges manifests in repoDir just like
+// Load(), but use the given local manifest instead of the content of its
+// source file. It is used to validate local manifest before saving.
+func LoadWithLocalManifest(repoDir string, local *Manifest) (*Manifest, error) {
+	file, err := manifestFile(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := loadManifests(repoDir, file)
+	if err != nil {
+		return nil, err
+	}
+	if manifests == nil {
+		return nil, fmt.Errorf("cannot find manifest in '%s'", repoDir)
+	}
+
+	// Make a copy of local manifest, for CheckAndFixup() will change it.
+	data, err := Marshal(local)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.CheckAndFixup(); err != nil {
+		return nil, withContactInfo(manifests,
+			fmt.Errorf("bad local manifest '%s': %s", local.SourceFile, err))
+	}
+	m.setSourceFile(local.SourceFile)
+
+	found := false
+	for i := range manifests {
+		if manifests[i].SourceFile == local.SourceFile {
+			manifests[i] = m
+			found = true
+			break
+		}
+	}
+	if !found {
+		manifests = append(manifests, m)
+	}
+
+	merged, err := mergeManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	if err = merged.Validate(); err != nil {
+		return nil, withContactInfo(manifests, err)
+	}
+	return merged, nil
+}
@@ -16,6 +16,7 @@
 package manifest
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -29,125 +30,159 @@ import (
 	"github.com/alibaba/git-repo-go/path"
 	"github.com/jiangxin/goconfig"
 	log "github.com/jiangxin/multi-log"
+	"gopkg.in/yaml.v2"
 )
 
 // Macros for manifest
 const (
 	maxRecursiveDepth = 10
+
+	FormatXML  = "xml"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
 )
 
 // Manifest is for toplevel XML structure.
 type Manifest struct {
-	XMLName        xml.Name        `xml:"manifest"`
-	Notice         string          `xml:"notice,omitempty"`
-	Remotes        []Remote        `xml:"remote,omitempty"`
-	Default        *Default        `xml:"default,omitempty"`
-	Server         *Server         `xml:"manifest-server,omitempty"`
-	Projects       []Project       `xml:"project,omitempty"`
-	RemoveProjects []RemoveProject `xml:"remove-project,omitempty"`
-	ExtendProjects []ExtendProject `xml:"extend-project,omitempty"`
-	RepoHooks      *RepoHooks      `xml:"repo-hooks,omitempty"`
-	Includes       []Include       `xml:"include,omitempty"`
-	SourceFile     string          `xml:"-"`
+	XMLName        xml.Name        `xml:"manifest" json:"-" yaml:"-"`
+	Notice         string          `xml:"notice,omitempty" json:"notice,omitempty" yaml:"notice,omitempty"`
+	Remotes        []Remote        `xml:"remote,omitempty" json:"remotes,omitempty" yaml:"remotes,omitempty"`
+	Default        *Default        `xml:"default,omitempty" json:"default,omitempty" yaml:"default,omitempty"`
+	Server         *Server         `xml:"manifest-server,omitempty" json:"manifest-server,omitempty" yaml:"manifest-server,omitempty"`
+	Projects       []Project       `xml:"project,omitempty" json:"projects,omitempty" yaml:"projects,omitempty"`
+	RemoveProjects []RemoveProject `xml:"remove-project,omitempty" json:"remove-projects,omitempty" yaml:"remove-projects,omitempty"`
+	ExtendProjects []ExtendProject `xml:"extend-project,omitempty" json:"extend-projects,omitempty" yaml:"extend-projects,omitempty"`
+	RepoHooks      *RepoHooks      `xml:"repo-hooks,omitempty" json:"repo-hooks,omitempty" yaml:"repo-hooks,omitempty"`
+	Superproject   *Superproject   `xml:"superproject,omitempty" json:"superproject,omitempty" yaml:"superproject,omitempty"`
+	ContactInfo    *ContactInfo    `xml:"contactinfo,omitempty" json:"contactinfo,omitempty" yaml:"contactinfo,omitempty"`
+	Includes       []Include       `xml:"include,omitempty" json:"includes,omitempty" yaml:"includes,omitempty"`
+	SourceFile     string          `xml:"-" json:"source-file,omitempty" yaml:"source-file,omitempty"`
 }
 
 // Remote is for remote XML element.
 type Remote struct {
-	Name     string `xml:"name,attr,omitempty"`
-	Alias    string `xml:"alias,attr,omitempty"`
-	Fetch    string `xml:"fetch,attr,omitempty"`
-	PushURL  string `xml:"pushurl,attr,omitempty"`
-	Override bool   `xml:"override,attr,omitempty"`
-	Review   string `xml:"review,attr,omitempty"`
-	Revision string `xml:"revision,attr,omitempty"`
-	Type     string `xml:"type,attr,omitempty"`
+	Name     string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Alias    string `xml:"alias,attr,omitempty" json:"alias,omitempty" yaml:"alias,omitempty"`
+	Fetch    string `xml:"fetch,attr,omitempty" json:"fetch,omitempty" yaml:"fetch,omitempty"`
+	PushURL  string `xml:"pushurl,attr,omitempty" json:"pushurl,omitempty" yaml:"pushurl,omitempty"`
+	Override bool   `xml:"override,attr,omitempty" json:"override,omitempty" yaml:"override,omitempty"`
+	Review   string `xml:"review,attr,omitempty" json:"review,omitempty" yaml:"review,omitempty"`
+	Revision string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
+	Type     string `xml:"type,attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+
+	// FetchURL is resolved from Fetch and manifest URL, only used for output.
+	FetchURL   string `xml:"-" json:"fetch-url,omitempty" yaml:"fetch-url,omitempty"`
+	SourceFile string `xml:"-" json:"source-file,omitempty" yaml:"source-file,omitempty"`
 }
 
 // Default is for default XML element.
 type Default struct {
-	RemoteName string `xml:"remote,attr,omitempty"`
-	Revision   string `xml:"revision,attr,omitempty"`
-	DestBranch string `xml:"dest-branch,attr,omitempty"`
-	Upstream   string `xml:"upstream,attr,omitempty"`
-	Override   bool   `xml:"override,attr,omitempty"`
-	SyncJ      int    `xml:"sync-j,attr,omitempty"`
-	SyncC      string `xml:"sync-c,attr,omitempty"`
-	SyncS      string `xml:"sync-s,attr,omitempty"`
-	SyncTags   string `xml:"sync-tags,attr,omitempty"`
+	RemoteName string `xml:"remote,attr,omitempty" json:"remote,omitempty" yaml:"remote,omitempty"`
+	Revision   string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
+	DestBranch string `xml:"dest-branch,attr,omitempty" json:"dest-branch,omitempty" yaml:"dest-branch,omitempty"`
+	Upstream   string `xml:"upstream,attr,omitempty" json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	Override   bool   `xml:"override,attr,omitempty" json:"override,omitempty" yaml:"override,omitempty"`
+	SyncJ      int    `xml:"sync-j,attr,omitempty" json:"sync-j,omitempty" yaml:"sync-j,omitempty"`
+	SyncC      string `xml:"sync-c,attr,omitempty" json:"sync-c,omitempty" yaml:"sync-c,omitempty"`
+	SyncS      string `xml:"sync-s,attr,omitempty" json:"sync-s,omitempty" yaml:"sync-s,omitempty"`
+	SyncTags   string `xml:"sync-tags,attr,omitempty" json:"sync-tags,omitempty" yaml:"sync-tags,omitempty"`
 }
 
 // Server is for manifest-server XML element.
 type Server struct {
-	Override bool   `xml:"override,attr,omitempty"`
-	URL      string `xml:"url,attr,omitempty"`
+	Override bool   `xml:"override,attr,omitempty" json:"override,omitempty" yaml:"override,omitempty"`
+	URL      string `xml:"url,attr,omitempty" json:"url,omitempty" yaml:"url,omitempty"`
 }
 
 // Project is for project XML element.
 type Project struct {
-	Annotations []Annotation `xml:"annotation,omitempty"`
-	Projects    []Project    `xml:"project,omitempty"`
-	CopyFiles   []CopyFile   `xml:"copyfile,omitempty"`
-	LinkFiles   []LinkFile   `xml:"linkfile,omitempty"`
-
-	Name       string `xml:"name,attr,omitempty"`
-	Path       string `xml:"path,attr,omitempty"`
-	RemoteName string `xml:"remote,attr,omitempty"`
-	Revision   string `xml:"revision,attr,omitempty"`
-	DestBranch string `xml:"dest-branch,attr,omitempty"`
-	Groups     string `xml:"groups,attr,omitempty"`
-	Rebase     string `xml:"rebase,attr,omitempty"`
-	SyncC      string `xml:"sync-c,attr,omitempty"`
-	SyncS      string `xml:"sync-s,attr,omitempty"`
-	SyncTags   string `xml:"sync-tags,attr,omitempty"`
-	Upstream   string `xml:"upstream,attr,omitempty"`
-	CloneDepth string `xml:"clone-depth,attr,omitempty"`
-	ForcePath  string `xml:"force-path,attr,omitempty"`
+	Annotations []Annotation `xml:"annotation,omitempty" json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Projects    []Project    `xml:"project,omitempty" json:"projects,omitempty" yaml:"projects,omitempty"`
+	CopyFiles   []CopyFile   `xml:"copyfile,omitempty" json:"copyfiles,omitempty" yaml:"copyfiles,omitempty"`
+	LinkFiles   []LinkFile   `xml:"linkfile,omitempty" json:"linkfiles,omitempty" yaml:"linkfiles,omitempty"`
+
+	Name       string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Path       string `xml:"path,attr,omitempty" json:"path,omitempty" yaml:"path,omitempty"`
+	RemoteName string `xml:"remote,attr,omitempty" json:"remote,omitempty" yaml:"remote,omitempty"`
+	Revision   string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
+	DestBranch string `xml:"dest-branch,attr,omitempty" json:"dest-branch,omitempty" yaml:"dest-branch,omitempty"`
+	Groups     string `xml:"groups,attr,omitempty" json:"groups,omitempty" yaml:"groups,omitempty"`
+	Rebase     string `xml:"rebase,attr,omitempty" json:"rebase,omitempty" yaml:"rebase,omitempty"`
+	SyncC      string `xml:"sync-c,attr,omitempty" json:"sync-c,omitempty" yaml:"sync-c,omitempty"`
+	SyncS      string `xml:"sync-s,attr,omitempty" json:"sync-s,omitempty" yaml:"sync-s,omitempty"`
+	SyncTags   string `xml:"sync-tags,attr,omitempty" json:"sync-tags,omitempty" yaml:"sync-tags,omitempty"`
+	Upstream   string `xml:"upstream,attr,omitempty" json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	CloneDepth string `xml:"clone-depth,attr,omitempty" json:"clone-depth,omitempty" yaml:"clone-depth,omitempty"`
+	ForcePath  string `xml:"force-path,attr,omitempty" json:"force-path,omitempty" yaml:"force-path,omitempty"`
+
+	// URL is resolved URL of the repository, only used for output.
+	URL        string `xml:"-" json:"url,omitempty" yaml:"url,omitempty"`
+	SourceFile string `xml:"-" json:"source-file,omitempty" yaml:"source-file,omitempty"`
 
 	isMetaProject  bool    `xml:"-"`
-	ManifestRemote *Remote `xml:"-"`
+	ManifestRemote *Remote `xml:"-" json:"-" yaml:"-"`
 }
 
 // Annotation is for annotation XML element.
 type Annotation struct {
-	Name  string `xml:"name,attr,omitempty"`
-	Value string `xml:"value,attr,omitempty"`
-	Keep  string `xml:"keep,attr,omitempty"`
+	Name  string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Value string `xml:"value,attr,omitempty" json:"value,omitempty" yaml:"value,omitempty"`
+	Keep  string `xml:"keep,attr,omitempty" json:"keep,omitempty" yaml:"keep,omitempty"`
 }
 
 // CopyFile is for copyfile XML element.
 type CopyFile struct {
-	Src  string `xml:"src,attr,omitempty"`
-	Dest string `xml:"dest,attr,omitempty"`
+	Src  string `xml:"src,attr,omitempty" json:"src,omitempty" yaml:"src,omitempty"`
+	Dest string `xml:"dest,attr,omitempty" json:"dest,omitempty" yaml:"dest,omitempty"`
 }
 
 // LinkFile is for linkfile XML element.
 type LinkFile struct {
-	Src  string `xml:"src,attr,omitempty"`
-	Dest string `xml:"dest,attr,omitempty"`
+	Src  string `xml:"src,attr,omitempty" json:"src,omitempty" yaml:"src,omitempty"`
+	Dest string `xml:"dest,attr,omitempty" json:"dest,omitempty" yaml:"dest,omitempty"`
 }
 
 // ExtendProject is for extend-project XML element.
 type ExtendProject struct {
-	Name     string `xml:"name,attr,omitempty"`
-	Path     string `xml:"path,attr,omitempty"`
-	Groups   string `xml:"groups,attr,omitempty"`
-	Revision string `xml:"revision,attr,omitempty"`
+	Name       string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Path       string `xml:"path,attr,omitempty" json:"path,omitempty" yaml:"path,omitempty"`
+	Groups     string `xml:"groups,attr,omitempty" json:"groups,omitempty" yaml:"groups,omitempty"`
+	Revision   string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
+	RemoteName string `xml:"remote,attr,omitempty" json:"remote,omitempty" yaml:"remote,omitempty"`
+	DestBranch string `xml:"dest-branch,attr,omitempty" json:"dest-branch,omitempty" yaml:"dest-branch,omitempty"`
+	Upstream   string `xml:"upstream,attr,omitempty" json:"upstream,omitempty" yaml:"upstream,omitempty"`
 }
 
 // RemoveProject is for remove-project XML element.
 type RemoveProject struct {
-	Name string `xml:"name,attr,omitempty"`
+	Name     string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Path     string `xml:"path,attr,omitempty" json:"path,omitempty" yaml:"path,omitempty"`
+	Optional string `xml:"optional,attr,omitempty" json:"optional,omitempty" yaml:"optional,omitempty"`
 }
 
 // RepoHooks is for repo-hooks XML element.
 type RepoHooks struct {
-	InProject   string `xml:"in-project,attr,omitempty"`
-	EnabledList string `xml:"enabled-list,attr,omitempty"`
+	InProject   string `xml:"in-project,attr,omitempty" json:"in-project,omitempty" yaml:"in-project,omitempty"`
+	EnabledList string `xml:"enabled-list,attr,omitempty" json:"enabled-list,omitempty" yaml:"enabled-list,omitempty"`
+}
+
+// Superproject is for superproject XML element.
+type Superproject struct {
+	Name       string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	RemoteName string `xml:"remote,attr,omitempty" json:"remote,omitempty" yaml:"remote,omitempty"`
+	Revision   string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
+}
+
+// ContactInfo is for contactinfo XML element.
+type ContactInfo struct {
+	BugURL string `xml:"bugurl,attr,omitempty" json:"bugurl,omitempty" yaml:"bugurl,omitempty"`
 }
 
 // Include is for include XML element.
 type Include struct {
-	Name string `xml:"name,attr,omitempty"`
+	Name     string `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Groups   string `xml:"groups,attr,omitempty" json:"groups,omitempty" yaml:"groups,omitempty"`
+	Revision string `xml:"revision,attr,omitempty" json:"revision,omitempty" yaml:"revision,omitempty"`
 }
 
 // CheckAndFixup will fixup "Manifest" element
@@ -177,10 +212,27 @@ func (v *Manifest) CheckAndFixup() error {
 			return err
 		}
 	}
+	if v.Superproject != nil {
+		if err := v.Superproject.CheckAndFixup(); err != nil {
+			return err
+		}
+	}
+	if v.ContactInfo != nil && v.ContactInfo.BugURL == "" {
+		return errors.New("\"contactinfo\" element has empty \"bugurl\"")
+	}
 
 	return nil
 }
 
+// CheckAndFixup will fixup "Superproject" element
+func (v *Superproject) CheckAndFixup() error {
+	if v.Name == "" {
+		return errors.New("\"superproject\" element has empty \"name\"")
+	}
+	v.Name = cleanPath(v.Name)
+	return nil
+}
+
 // CheckAndFixup will fixup "Remote" element
 func (v *Remote) CheckAndFixup() error {
 	if v.Name == "" {
@@ -189,6 +241,15 @@ func (v *Remote) CheckAndFixup() error {
 	return nil
 }
 
+// sameAs compares two remotes, and ignores fields not from XML.
+func (v Remote) sameAs(r *Remote) bool {
+	r1 := v
+	r2 := *r
+	r1.FetchURL, r2.FetchURL = "", ""
+	r1.SourceFile, r2.SourceFile = "", ""
+	return reflect.DeepEqual(r1, r2)
+}
+
 // CheckAndFixup will fixup "Include" element
 func (v *Include) CheckAndFixup() error {
 	if v.Name == "" {
@@ -228,27 +289,52 @@ func (v *Project) CheckAndFixup() error {
 
 // CheckAndFixup will fixup "RemoveProject" element
 func (v *RemoveProject) CheckAndFixup() error {
-	if v.Name == "" {
-		return errors.New("\"remove-project\" element has empty \"name\"")
+	if v.Name == "" && v.Path == "" {
+		return errors.New("\"remove-project\" element has neither \"name\" nor \"path\"")
+	}
+	if v.Name != "" {
+		v.Name = cleanPath(v.Name)
+	}
+	if v.Path != "" {
+		v.Path = cleanPath(v.Path)
 	}
-	v.Name = cleanPath(v.Name)
 	return nil
 }
 
+// IsOptional indicates it is not an error if no project matches.
+func (v RemoveProject) IsOptional() bool {
+	return isTrue(v.Optional, false)
+}
+
+// Match tests whether project p matches name and path of remove-project.
+func (v RemoveProject) Match(p *Project) bool {
+	if v.Name != "" && v.Name != p.Name {
+		return false
+	}
+	if v.Path != "" && v.Path != p.Path {
+		return false
+	}
+	return true
+}
+
 // CheckAndFixup will fixup "ExtendProject" element
 func (v *ExtendProject) CheckAndFixup() error {
 	if v.Name == "" {
 		return errors.New("\"extend-project\" element has empty \"name\"")
 	}
 	v.Name = cleanPath(v.Name)
-	if v.Path == "" {
-		v.Path = v.Name
-	} else {
+	if v.Path != "" {
 		v.Path = cleanPath(v.Path)
 	}
 	return nil
 }
 
+// Match tests whether project p matches name and path of extend-project.
+// Path is optional, if not specified, matches all projects with the name.
+func (v ExtendProject) Match(p *Project) bool {
+	return v.Name == p.Name && (v.Path == "" || v.Path == p.Path)
+}
+
 // CheckAndFixup will fixup "copyfile" element
 func (v *CopyFile) CheckAndFixup() error {
 	if v.Src == "" {
@@ -312,6 +398,8 @@ func (v *Project) AllProjects(parent *Project) []Project {
 			Upstream:    v.Upstream,
 			CloneDepth:  v.CloneDepth,
 			ForcePath:   v.ForcePath,
+			URL:         v.URL,
+			SourceFile:  v.SourceFile,
 		}
 		projects = append(projects, project)
 	} else {
@@ -384,7 +472,7 @@ func (v *Manifest) AllProjects() []Project {
 	for i := range projects {
 		if projects[i].RemoteName == "" {
 			if v.Default == nil || v.Default.RemoteName == "" {
-				log.Fatalf("no remote defined for for project '%s'",
+				log.Fatalf("no remote defined for project '%s'",
 					projects[i].Name)
 			}
 			projects[i].RemoteName = v.Default.RemoteName
@@ -428,6 +516,47 @@ func (v *Manifest) AllProjects() []Project {
 	return projects
 }
 
+// Validate checks whether remote and revision of each project are defined.
+func (v *Manifest) Validate() error {
+	remotes := make(map[string]*Remote)
+	for i := range v.Remotes {
+		remotes[v.Remotes[i].Name] = &v.Remotes[i]
+	}
+
+	for _, p := range v.allProjects() {
+		remoteName := p.RemoteName
+		if remoteName == "" && v.Default != nil {
+			remoteName = v.Default.RemoteName
+		}
+		if remoteName == "" {
+			return fmt.Errorf("no remote defined for project '%s'", p.Name)
+		}
+		remote, ok := remotes[remoteName]
+		if !ok {
+			return fmt.Errorf("cannot find remote '%s' for project '%s'",
+				remoteName,
+				p.Name)
+		}
+		if p.Revision == "" && remote.Revision == "" &&
+			(v.Default == nil || v.Default.Revision == "") {
+			return fmt.Errorf("no revision for project '%s'", p.Name)
+		}
+	}
+
+	if v.Superproject != nil {
+		remoteName := v.Superproject.RemoteName
+		if remoteName == "" && v.Default != nil {
+			remoteName = v.Default.RemoteName
+		}
+		if _, ok := remotes[remoteName]; !ok {
+			return fmt.Errorf("cannot find remote '%s' for superproject '%s'",
+				remoteName,
+				v.Superproject.Name)
+		}
+	}
+	return nil
+}
+
 // Merge implements merging another manifest to self.
 func (v *Manifest) Merge(m *Manifest) error {
 	if m.Notice != "" {
@@ -444,7 +573,7 @@ func (v *Manifest) Merge(m *Manifest) error {
 			if r1.Name == r2.Name {
 				if r1.Override {
 					v.Remotes[idx] = r1
-				} else if !reflect.DeepEqual(r1, r2) {
+				} else if !r1.sameAs(&r2) {
 					return fmt.Errorf("duplicate remote in %s. If you want to override, set atrribute 'override' true",
 						m.SourceFile)
 				}
@@ -474,6 +603,19 @@ func (v *Manifest) Merge(m *Manifest) error {
 		}
 	}
 
+	if m.Superproject != nil {
+		if v.Superproject == nil {
+			v.Superproject = m.Superproject
+		} else if !reflect.DeepEqual(v.Superproject, m.Superproject) {
+			return fmt.Errorf("duplicate superproject in %s", m.SourceFile)
+		}
+	}
+
+	// The last contactinfo wins.
+	if m.ContactInfo != nil {
+		v.ContactInfo = m.ContactInfo
+	}
+
 	realPath := make(map[string]bool)
 	for _, p := range v.allProjects() {
 		if realPath[p.Path] {
@@ -493,38 +635,36 @@ func (v *Manifest) Merge(m *Manifest) error {
 		realPath[p.Path] = true
 	}
 
-	rmPath := make(map[string]bool)
-	for _, r := range m.RemoveProjects {
-		rmPath[r.Name] = true
-	}
-	ps := []Project{}
-	for _, p := range v.allProjects() {
-		if rmPath[p.Name] {
-			realPath[p.Path] = false
-		} else {
-			ps = append(ps, p)
+	if len(m.RemoveProjects) > 0 {
+		ps := []Project{}
+		matched := make([]bool, len(m.RemoveProjects))
+		for _, p := range v.allProjects() {
+			removed := false
+			for i, r := range m.RemoveProjects {
+				if r.Match(&p) {
+					matched[i] = true
+					removed = true
+				}
+			}
+			if !removed {
+				ps = append(ps, p)
+			}
+		}
+		for i, r := range m.RemoveProjects {
+			if !matched[i] && !r.IsOptional() {
+				log.Warnf("remove-project element specifies non-existent project (name: '%s', path: '%s') in '%s'",
+					r.Name,
+					r.Path,
+					m.SourceFile)
+			}
 		}
+		v.Projects = ps
 	}
-	v.Projects = ps
 
-	extPath := make(map[string]ExtendProject)
-	for _, p := range m.ExtendProjects {
-		extPath[p.Name] = p
-	}
-	for i, p := range v.Projects {
-		if p2, ok := extPath[p.Name]; ok {
-			if p2.Path == p.Path {
-				if p.Groups == "" {
-					v.Projects[i].Groups = p2.Groups
-				} else if p2.Groups != "" {
-					groups := []string{}
-					groups = append(groups, strings.Split(p.Groups, ",")...)
-					groups = append(groups, strings.Split(p2.Groups, ",")...)
-					v.Projects[i].Groups = strings.Join(groups, ",")
-				}
-				if p2.Revision != "" {
-					v.Projects[i].Revision = p2.Revision
-				}
+	for _, ext := range m.ExtendProjects {
+		for i := range v.Projects {
+			if ext.Match(&v.Projects[i]) {
+				v.Projects[i].extend(&ext)
 			}
 		}
 	}
@@ -534,6 +674,45 @@ func (v *Manifest) Merge(m *Manifest) error {
 	return nil
 }
 
+// extend changes attributes of project using extend-project element.
+func (v *Project) extend(ext *ExtendProject) {
+	v.Groups = joinGroups(v.Groups, ext.Groups)
+	if ext.Revision != "" {
+		v.Revision = ext.Revision
+	}
+	if ext.RemoteName != "" {
+		v.RemoteName = ext.RemoteName
+	}
+	if ext.DestBranch != "" {
+		v.DestBranch = ext.DestBranch
+	}
+	if ext.Upstream != "" {
+		v.Upstream = ext.Upstream
+	}
+}
+
+// include applies groups and revision of include element on project and
+// its sub-projects. Revision only applies on projects without revision.
+func (v *Project) include(inc *Include) {
+	v.Groups = joinGroups(inc.Groups, v.Groups)
+	if v.Revision == "" {
+		v.Revision = inc.Revision
+	}
+	for i := range v.Projects {
+		v.Projects[i].include(inc)
+	}
+}
+
+// joinGroups joins two comma separated groups.
+func joinGroups(groups1, groups2 string) string {
+	if groups1 == "" {
+		return groups2
+	} else if groups2 == "" {
+		return groups1
+	}
+	return groups1 + "," + groups2
+}
+
 // ProjectHandler is an interface to manipulate projects of manifest
 type ProjectHandler interface {
 	// The 1st parameter is pointer of a project, and the 2nd parameter
@@ -577,6 +756,25 @@ func (v *Project) execute(handle ProjectHandler, path string, depth int) error {
 	return nil
 }
 
+// setSourceFile saves name of the XML file in manifest, remotes and projects,
+// so we can tell where they come from after manifests are merged.
+func (v *Manifest) setSourceFile(file string) {
+	v.SourceFile = file
+	for i := range v.Remotes {
+		v.Remotes[i].SourceFile = file
+	}
+	for i := range v.Projects {
+		v.Projects[i].setSourceFile(file)
+	}
+}
+
+func (v *Project) setSourceFile(file string) {
+	v.SourceFile = file
+	for i := range v.Projects {
+		v.Projects[i].setSourceFile(file)
+	}
+}
+
 func cleanPath(name string) string {
 	return filepath.Clean(strings.Replace(strings.TrimSuffix(name, ".git"), "\\", "/", -1))
 }
@@ -591,9 +789,10 @@ func unmarshalFile(file string) (*Manifest, error) {
 		return nil, fmt.Errorf("cannot read manifest file '%s': %s", file, err)
 	}
 
+	// Returns the partially decoded manifest, which may have contact info.
 	ms, err := Unmarshal(buf)
 	if err != nil {
-		return nil, fmt.Errorf("fail to parse manifest file '%s': %s", file, err)
+		return ms, fmt.Errorf("fail to parse manifest file '%s': %s", file, err)
 	}
 
 	return ms, nil
@@ -604,6 +803,9 @@ func parseXML(file string, depth int) ([]*Manifest, error) {
 
 	m, err := unmarshalFile(file)
 	if err != nil {
+		if m != nil && m.ContactInfo != nil {
+			ms = append(ms, &Manifest{ContactInfo: m.ContactInfo})
+		}
 		return ms, err
 	}
 	if m == nil {
@@ -612,7 +814,7 @@ func parseXML(file string, depth int) ([]*Manifest, error) {
 	if err := m.CheckAndFixup(); err != nil {
 		return ms, err
 	}
-	m.SourceFile = file
+	m.setSourceFile(file)
 	ms = append(ms, m)
 
 	for _, i := range m.Includes {
@@ -622,7 +824,7 @@ func parseXML(file string, depth int) ([]*Manifest, error) {
 		}
 
 		if depth > maxRecursiveDepth {
-			return nil, fmt.Errorf("exceeded maximum include depth (%d) while including\n"+
+			return ms, fmt.Errorf("exceeded maximum include depth (%d) while including\n"+
 				"\t%s\n"+
 				"from"+
 				"\t%s\n"+
@@ -634,7 +836,14 @@ func parseXML(file string, depth int) ([]*Manifest, error) {
 
 		subMs, err := parseXML(f, depth+1)
 		if err != nil {
-			return ms, err
+			return append(ms, subMs...), err
+		}
+		if i.Groups != "" || i.Revision != "" {
+			for _, subM := range subMs {
+				for j := range subM.Projects {
+					subM.Projects[j].include(&i)
+				}
+			}
 		}
 		ms = append(ms, subMs...)
 	}
@@ -647,26 +856,36 @@ func mergeManifests(ms []*Manifest) (*Manifest, error) {
 	for _, m := range ms {
 		err := manifest.Merge(m)
 		if err != nil {
-			return nil, err
+			return nil, withContactInfo(ms, err)
 		}
 	}
 	return manifest, nil
 }
 
-// Load implements load and parse manifest XML file in repoDir.
-func Load(repoDir string) (*Manifest, error) {
-	var (
-		file string
-		err  error
-	)
+// withContactInfo appends bug report URL defined in contactinfo element of
+// manifests to the error message, so users know where to report problems.
+func withContactInfo(ms []*Manifest, err error) error {
+	bugURL := ""
+	for _, m := range ms {
+		if m != nil && m.ContactInfo != nil && m.ContactInfo.BugURL != "" {
+			bugURL = m.ContactInfo.BugURL
+		}
+	}
+	if err == nil || bugURL == "" {
+		return err
+	}
+	return fmt.Errorf("%s\nPlease report problems of the manifest to: %s", err, bugURL)
+}
 
-	file = filepath.Join(repoDir, config.ManifestXML)
-	if _, err = os.Stat(file); err != nil {
+// manifestFile returns the manifest file in repoDir.
+func manifestFile(repoDir string) (string, error) {
+	file := filepath.Join(repoDir, config.ManifestXML)
+	if _, err := os.Stat(file); err != nil {
 		defaultXML := ""
 		manifestsDir := filepath.Join(repoDir, config.Manifests)
 		cfg, err := goconfig.Load(manifestsDir)
 		if err != nil && err != goconfig.ErrNotExist {
-			return nil, fmt.Errorf("fail to read config from %s: %s", manifestsDir, err)
+			return "", fmt.Errorf("fail to read config from %s: %s", manifestsDir, err)
 		}
 		if cfg != nil {
 			defaultXML = cfg.Get(config.CfgManifestName)
@@ -676,14 +895,33 @@ func Load(repoDir string) (*Manifest, error) {
 		}
 		file = filepath.Join(manifestsDir, defaultXML)
 		if _, err = os.Stat(file); err != nil {
-			return nil, err
+			return "", err
 		}
 	}
+	return file, nil
+}
+
+// Load implements load and parse manifest XML file in repoDir.
+func Load(repoDir string) (*Manifest, error) {
+	file, err := manifestFile(repoDir)
+	if err != nil {
+		return nil, err
+	}
 	return LoadFile(repoDir, file)
 }
 
 // LoadFile implements load specific manifest file inside repoDir.
 func LoadFile(repoDir, file string) (*Manifest, error) {
+	manifests, err := loadManifests(repoDir, file)
+	if err != nil || manifests == nil {
+		return nil, err
+	}
+	return mergeManifests(manifests)
+}
+
+// loadManifests parses manifest file and local manifests in repoDir,
+// and returns manifests not merged yet.
+func loadManifests(repoDir, file string) ([]*Manifest, error) {
 	var (
 		dir       string
 		err       error
@@ -701,7 +939,7 @@ func LoadFile(repoDir, file string) (*Manifest, error) {
 
 	ms, err := parseXML(file, 1)
 	if err != nil {
-		return nil, err
+		return nil, withContactInfo(ms, err)
 	}
 	manifests = append(manifests, ms...)
 
@@ -715,7 +953,24 @@ func LoadFile(repoDir, file string) (*Manifest, error) {
 	}
 
 	// load xml files in local_manifests
-	if _, err = os.Stat(dir); err == nil {
+	files = append(files, LocalManifestFiles(repoDir)...)
+
+	for _, file = range files {
+		ms, err := parseXML(file, 1)
+		if err != nil {
+			return nil, withContactInfo(append(manifests, ms...), err)
+		}
+		manifests = append(manifests, ms...)
+	}
+
+	return manifests, nil
+}
+
+// LocalManifestFiles returns XML files in local_manifests dir of repoDir.
+func LocalManifestFiles(repoDir string) []string {
+	files := []string{}
+	dir := filepath.Join(repoDir, config.LocalManifests)
+	if _, err := os.Stat(dir); err == nil {
 		filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
@@ -732,16 +987,7 @@ func LoadFile(repoDir, file string) (*Manifest, error) {
 			return nil
 		})
 	}
-
-	for _, file = range files {
-		ms, err := parseXML(file, 1)
-		if err != nil {
-			return nil, err
-		}
-		manifests = append(manifests, ms...)
-	}
-
-	return mergeManifests(manifests)
+	return files
 }
 
 // Unmarshal implements decoding XML (in buf) to manifest.
@@ -757,6 +1003,19 @@ func Marshal(ms *Manifest) ([]byte, error) {
 	return xml.MarshalIndent(ms, "", "  ")
 }
 
+// MarshalFormat implements encoding manifest to XML, JSON or YAML.
+func MarshalFormat(ms *Manifest, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "", FormatXML:
+		return Marshal(ms)
+	case FormatJSON:
+		return json.MarshalIndent(ms, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(ms)
+	}
+	return nil, fmt.Errorf("unknown manifest format '%s'", format)
+}
+
 // ManifestsProject is a special instance of Project.
 var ManifestsProject = &Project{
 	Name:          "manifests",
@@ -93,6 +93,86 @@ func TestMarshal(t *testing.T) {
 	assert.Equal(expected, string(actual))
 }
 
+func TestMarshalFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	m := Manifest{
+		Remotes: []Remote{
+			Remote{
+				Name:       "aone",
+				Fetch:      ".",
+				FetchURL:   "https://example.com",
+				SourceFile: "manifest.xml",
+			},
+		},
+		Default: &Default{
+			RemoteName: "aone",
+			Revision:   "master",
+		},
+		Projects: []Project{
+			Project{
+				Name:       "platform/manifest",
+				Path:       "platform-manifest",
+				URL:        "https://example.com/platform/manifest.git",
+				SourceFile: "local_manifests/local.xml",
+			},
+		},
+	}
+
+	actual, err := MarshalFormat(&m, FormatJSON)
+	assert.Nil(err)
+	assert.Equal(`{
+  "remotes": [
+    {
+      "name": "aone",
+      "fetch": ".",
+      "fetch-url": "https://example.com",
+      "source-file": "manifest.xml"
+    }
+  ],
+  "default": {
+    "remote": "aone",
+    "revision": "master"
+  },
+  "projects": [
+    {
+      "name": "platform/manifest",
+      "path": "platform-manifest",
+      "url": "https://example.com/platform/manifest.git",
+      "source-file": "local_manifests/local.xml"
+    }
+  ]
+}`, string(actual))
+
+	actual, err = MarshalFormat(&m, FormatYAML)
+	assert.Nil(err)
+	assert.Equal(`remotes:
+- name: aone
+  fetch: .
+  fetch-url: https://example.com
+  source-file: manifest.xml
+default:
+  remote: aone
+  revision: master
+projects:
+- name: platform/manifest
+  path: platform-manifest
+  url: https://example.com/platform/manifest.git
+  source-file: local_manifests/local.xml
+`, string(actual))
+
+	actual, err = MarshalFormat(&m, FormatXML)
+	assert.Nil(err)
+	assert.Equal(`<manifest>
+  <remote name="aone" fetch="."></remote>
+  <default remote="aone" revision="master"></default>
+  <project name="platform/manifest" path="platform-manifest"></project>
+</manifest>`, string(actual))
+
+	_, err = MarshalFormat(&m, "toml")
+	assert.NotNil(err)
+}
+
 func TestUnmarshal(t *testing.T) {
 	assert := assert.New(t)
 
@@ -104,6 +184,51 @@ func TestUnmarshal(t *testing.T) {
 	assert.Equal(manifest.Projects, m.Projects)
 }
 
+func TestSuperprojectAndContactInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	data := `<manifest>
+  <remote name="aone" fetch="."></remote>
+  <default remote="aone" revision="master"></default>
+  <superproject name="superproject" remote="aone" revision="main"></superproject>
+  <contactinfo bugurl="https://example.com/bugs"></contactinfo>
+</manifest>`
+	m, err := Unmarshal([]byte(data))
+	assert.Nil(err)
+	assert.Nil(m.CheckAndFixup())
+	assert.Equal(&Superproject{
+		Name:       "superproject",
+		RemoteName: "aone",
+		Revision:   "main",
+	}, m.Superproject)
+	assert.Equal(&ContactInfo{BugURL: "https://example.com/bugs"}, m.ContactInfo)
+	assert.Nil(m.Validate())
+
+	out, err := Marshal(m)
+	assert.Nil(err)
+	assert.Equal(data, string(out))
+
+	m2, err := Unmarshal([]byte(`<manifest>
+  <superproject name="superproject2"></superproject>
+  <contactinfo bugurl="https://example.com/new-bugs"></contactinfo>
+</manifest>`))
+	assert.Nil(err)
+	merged, err := mergeManifests([]*Manifest{m, m2})
+	assert.Nil(merged)
+	assert.Equal("duplicate superproject in \n"+
+		"Please report problems of the manifest to: https://example.com/new-bugs",
+		err.Error())
+
+	m2.Superproject = nil
+	merged, err = mergeManifests([]*Manifest{m, m2})
+	assert.Nil(err)
+	assert.Equal("https://example.com/new-bugs", merged.ContactInfo.BugURL)
+
+	m.Superproject.RemoteName = "bad"
+	assert.Equal("cannot find remote 'bad' for superproject 'superproject'",
+		m.Validate().Error())
+}
+
 func TestLoad(t *testing.T) {
 	assert := assert.New(t)
 
@@ -152,11 +277,12 @@ func TestLoad(t *testing.T) {
 		}, m.Default)
 	assert.Equal(
 		[]Remote{Remote{
-			Name:     "aone",
-			Alias:    "origin",
-			Fetch:    "https://example.com",
-			Review:   "https://example.com",
-			Revision: "default"},
+			Name:       "aone",
+			Alias:      "origin",
+			Fetch:      "https://example.com",
+			Review:     "https://example.com",
+			Revision:   "default",
+			SourceFile: manifestFile},
 		}, m.Remotes)
 	projects := []string{}
 	for _, p := range m.AllProjects() {
@@ -238,6 +364,138 @@ func TestInclude(t *testing.T) {
 	}
 }
 
+func TestIncludeWithGroupsAndRevision(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "git-repo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(dir string) {
+		os.RemoveAll(dir)
+	}(tmpdir)
+
+	repoDir := filepath.Join(tmpdir, "workdir", ".repo")
+	err = os.MkdirAll(repoDir, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(repoDir, "manifest.xml"), []byte(`
+<manifest>
+  <remote name="aone" fetch="https://example.com"></remote>
+  <default remote="aone" revision="master"></default>
+  <project name="platform/manifest" path="platform-manifest"></project>
+  <include name="foo.xml" groups="foo" revision="refs/heads/foo"></include>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	err = ioutil.WriteFile(filepath.Join(repoDir, "foo.xml"), []byte(`
+<manifest>
+  <project name="platform/foo" path="foo" groups="app">
+    <project name="sub" path="sub"></project>
+  </project>
+  <project name="platform/bar" path="bar" revision="v1.0"></project>
+  <include name="baz.xml"></include>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	err = ioutil.WriteFile(filepath.Join(repoDir, "baz.xml"), []byte(`
+<manifest>
+  <project name="platform/baz" path="baz"></project>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	m, err := Load(repoDir)
+	assert.Nil(err)
+	projects := []string{}
+	for _, p := range m.Projects {
+		projects = append(projects,
+			fmt.Sprintf("%s:%s:%s", p.Name, p.Groups, p.Revision))
+	}
+	assert.Equal([]string{
+		"platform/manifest::",
+		"platform/foo:foo,app:refs/heads/foo",
+		"platform/foo/sub:foo:refs/heads/foo",
+		"platform/bar:foo:v1.0",
+		"platform/baz:foo:refs/heads/foo",
+	}, projects)
+}
+
+func TestExtendAndRemoveProject(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "git-repo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(dir string) {
+		os.RemoveAll(dir)
+	}(tmpdir)
+
+	repoDir := filepath.Join(tmpdir, "workdir", ".repo")
+	err = os.MkdirAll(filepath.Join(repoDir, "local_manifests"), 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(repoDir, "manifest.xml"), []byte(`
+<manifest>
+  <remote name="aone" fetch="https://example.com"></remote>
+  <remote name="github" fetch="https://github.com"></remote>
+  <default remote="aone" revision="master"></default>
+  <project name="platform/foo" path="foo" groups="app"></project>
+  <project name="platform/foo" path="foo-v2" revision="v2"></project>
+  <project name="platform/bar" path="bar"></project>
+  <project name="platform/baz" path="baz"></project>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	localFile := filepath.Join(repoDir, "local_manifests", "local.xml")
+	err = ioutil.WriteFile(localFile, []byte(`
+<manifest>
+  <extend-project name="platform/foo" groups="team" remote="github" dest-branch="dev" upstream="main"></extend-project>
+  <extend-project name="platform/foo" path="foo-v2" revision="v2.1"></extend-project>
+  <remove-project path="bar"></remove-project>
+  <remove-project name="platform/baz" path="not-exist" optional="true"></remove-project>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	m, err := Load(repoDir)
+	assert.Nil(err)
+	assert.Equal(3, len(m.Projects))
+	for _, p := range m.Projects[:2] {
+		assert.Equal("platform/foo", p.Name)
+		assert.Equal("github", p.RemoteName)
+		assert.Equal("dev", p.DestBranch)
+		assert.Equal("main", p.Upstream)
+	}
+	assert.Equal("app,team", m.Projects[0].Groups)
+	assert.Equal("", m.Projects[0].Revision)
+	assert.Equal("team", m.Projects[1].Groups)
+	assert.Equal("v2.1", m.Projects[1].Revision)
+	assert.Equal("platform/baz", m.Projects[2].Name)
+
+	// remove-project which matches nothing and is not optional, only warns
+	err = ioutil.WriteFile(localFile, []byte(`
+<manifest>
+  <remove-project name="platform/baz" path="not-exist"></remove-project>
+</manifest>`), 0644)
+	assert.Nil(err)
+	m, err = Load(repoDir)
+	assert.Nil(err)
+	assert.Equal(4, len(m.Projects))
+
+	// remove-project without name and path
+	err = ioutil.WriteFile(localFile, []byte(`
+<manifest>
+  <remove-project optional="true"></remove-project>
+</manifest>`), 0644)
+	assert.Nil(err)
+	_, err = Load(repoDir)
+	assert.NotNil(err)
+}
+
 func TestLoadWithLocalManifest(t *testing.T) {
 	assert := assert.New(t)
 
@@ -803,3 +1061,73 @@ func ExampleUnmarshal() {
 	// project #2> name: platform/drivers/platform/nic, path: platform-drivers/nic
 	// project #3> name: platform/manifest, path: platform-manifest
 }
+
+func TestEditLocalManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "git-repo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(dir string) {
+		os.RemoveAll(dir)
+	}(tmpdir)
+
+	repoDir := filepath.Join(tmpdir, "workdir", ".repo")
+	err = os.MkdirAll(repoDir, 0755)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// create manifest.xml
+	manifestFile := filepath.Join(repoDir, "manifest.xml")
+	err = ioutil.WriteFile(manifestFile, []byte(`
+<manifest>
+  <remote name="aone" fetch="https://example.com" review="https://example.com"></remote>
+  <default remote="aone" revision="master"></default>
+  <project name="platform/manifest" path="platform-manifest"></project>
+</manifest>`), 0644)
+	assert.Nil(err)
+
+	file := LocalManifestFile(repoDir, "local")
+	assert.Equal(filepath.Join(repoDir, "local_manifests", "local.xml"), file)
+	local, err := LoadLocalManifest(file)
+	assert.Nil(err)
+	assert.Equal(file, local.SourceFile)
+
+	// Bad remote
+	local.Projects = []Project{{Name: "tools/git-repo", RemoteName: "bad"}}
+	_, err = LoadWithLocalManifest(repoDir, local)
+	assert.Equal("cannot find remote 'bad' for project 'tools/git-repo'", err.Error())
+
+	// Duplicate path
+	local.Projects = []Project{{Name: "tools/git-repo", Path: "platform-manifest"}}
+	_, err = LoadWithLocalManifest(repoDir, local)
+	assert.NotNil(err)
+
+	local.Projects = []Project{{Name: "tools/git-repo"}}
+	local.ExtendProjects = []ExtendProject{
+		{Name: "platform/manifest", Path: "platform-manifest", Revision: "dev"},
+	}
+	m, err := LoadWithLocalManifest(repoDir, local)
+	assert.Nil(err)
+	assert.Equal(2, len(m.Projects))
+	assert.Equal("dev", m.Projects[0].Revision)
+	assert.Equal("tools/git-repo", m.Projects[1].Path)
+
+	// Local manifest is not changed by validation
+	assert.Equal("", local.Projects[0].Path)
+	assert.Nil(SaveLocalManifest(local))
+	data, err := ioutil.ReadFile(file)
+	assert.Nil(err)
+	assert.Equal(`<manifest>
+  <project name="tools/git-repo"></project>
+  <extend-project name="platform/manifest" path="platform-manifest" revision="dev"></extend-project>
+</manifest>
+`, string(data))
+	assert.Equal([]string{file}, LocalManifestFiles(repoDir))
+
+	// Error other than not exist is not ignored
+	_, err = LoadLocalManifest(filepath.Join(file, "local.xml"))
+	assert.NotNil(err)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alibaba/git-repo-go/common"
 	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
 	log "github.com/jiangxin/multi-log"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 )
@@ -74,6 +75,32 @@ func (v Repository) TrackRemote(branch string) string {
 	return cfg.Get("branch." + branch + ".remote")
 }
 
+// BranchReview gets code review last uploaded from branch.
+func (v Repository) BranchReview(branch string) helper.ReviewResult {
+	branch = strings.TrimPrefix(branch, config.RefsHeads)
+
+	cfg := v.Config()
+	return helper.ReviewResult{
+		ID:  cfg.Get("branch." + branch + ".reviewid"),
+		URL: cfg.Get("branch." + branch + ".reviewurl"),
+	}
+}
+
+// SetBranchReview saves code review uploaded from branch, which can be
+// reused by later uploads.
+func (v *Repository) SetBranchReview(branch string, review helper.ReviewResult) error {
+	branch = strings.TrimPrefix(branch, config.RefsHeads)
+
+	cfg := v.Config()
+	cfg.Set("branch."+branch+".reviewid", review.ID)
+	if review.URL != "" {
+		cfg.Set("branch."+branch+".reviewurl", review.URL)
+	} else {
+		cfg.Unset("branch." + branch + ".reviewurl")
+	}
+	return v.SaveConfig(cfg)
+}
+
 // LocalTrackBranch gets local tracking remote branch
 func (v Repository) LocalTrackBranch(branch string) string {
 	if branch == "" {
@@ -2,9 +2,14 @@ package project
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/alibaba/git-repo-go/color"
 	log "github.com/jiangxin/multi-log"
 )
 
@@ -27,6 +32,11 @@ func (v Project) DownloadPatchSet(remoteName string, reviewID, patchID int) (*Pa
 		remote = v.GetDefaultRemote(true)
 	}
 	if remote == nil || !remote.ProtoHelperReady() {
+		// Use code review fetched by "sync --fetch-reviews" if offline.
+		if localRef := v.LocalReviewRef(reviewID, patchID); localRef != "" {
+			log.Warnf("%sno remote to download, use '%s' instead", v.Prompt(), localRef)
+			return v.newPatchSet(localRef)
+		}
 		log.Fatalf("%snot remote tracking defined, and do not know where to download",
 			v.Prompt())
 	}
@@ -49,9 +59,19 @@ func (v Project) DownloadPatchSet(remoteName string, reviewID, patchID int) (*Pa
 	log.Debugf("%swill execute: %s", v.Prompt(), strings.Join(cmdArgs, " "))
 	err = executeCommandIn(v.WorkDir, cmdArgs)
 	if err != nil {
-		return nil, err
+		localRef := v.LocalReviewRef(reviewID, patchID)
+		if localRef == "" {
+			return nil, err
+		}
+		log.Warnf("%sfail to fetch code review, use '%s' instead", v.Prompt(), localRef)
+		reviewRef = localRef
 	}
+	return v.newPatchSet(reviewRef)
+}
 
+// newPatchSet returns PatchSet of reviewRef, which has commits not merged
+// into HEAD.
+func (v Project) newPatchSet(reviewRef string) (*PatchSet, error) {
 	commits, err := v.Revlist(reviewRef, "--not", "HEAD")
 	if err != nil {
 		return nil, err
@@ -75,6 +95,85 @@ func (v Project) DownloadPatchSet(remoteName string, reviewID, patchID int) (*Pa
 	return &dl, nil
 }
 
+// patchSetBase returns base of patch set, which is the merge base of the
+// patch set and the upstream branch. If upstream branch is unknown, use
+// parent of the first commit of the patch set instead.
+func (v Project) patchSetBase(dl *PatchSet, upstream string) (string, error) {
+	if upstream != "" {
+		result := v.ExecuteCommand(GIT, "merge-base", upstream, dl.Commit)
+		if result.Success() {
+			return strings.TrimSpace(result.Stdout()), nil
+		}
+		log.Debugf("%sfail to find merge base of %s and %s: %s",
+			v.Prompt(),
+			upstream,
+			dl.Commit,
+			strings.TrimSpace(result.Stderr()))
+	}
+	first := dl.Commit
+	if len(dl.Commits) > 0 {
+		first = dl.Commits[len(dl.Commits)-1]
+	}
+	return v.ResolveRevision(first + "^")
+}
+
+// interdiffUpstream returns upstream branch to find bases of patch sets.
+func (v Project) interdiffUpstream(remoteName string) string {
+	if track := v.LocalTrackBranch(""); track != "" {
+		return track
+	}
+	if remoteName == "" {
+		remoteName = v.RemoteName
+	}
+	if remoteName == "" {
+		return ""
+	}
+	if rev := v.DefaultTrackingBranch(); rev != "" {
+		return v.RemoteMatchingBranch(remoteName, rev)
+	}
+	return ""
+}
+
+// Interdiff shows changes between two patch sets of a code review. If the
+// two patch sets have different bases (e.g. rebased), range-diff is shown
+// instead of a plain diff.
+func (v Project) Interdiff(remoteName string, reviewID, patch1, patch2 int) error {
+	dl1, err := v.DownloadPatchSet(remoteName, reviewID, patch1)
+	if err != nil {
+		return err
+	}
+	dl2, err := v.DownloadPatchSet(remoteName, reviewID, patch2)
+	if err != nil {
+		return err
+	}
+	upstream := v.interdiffUpstream(remoteName)
+	base1, err := v.patchSetBase(dl1, upstream)
+	if err != nil {
+		return fmt.Errorf("cannot find base of patch set %d: %s", patch1, err)
+	}
+	base2, err := v.patchSetBase(dl2, upstream)
+	if err != nil {
+		return fmt.Errorf("cannot find base of patch set %d: %s", patch2, err)
+	}
+
+	colorOption := "--no-color"
+	if color.Enabled() {
+		colorOption = "--color"
+	}
+	cmdArgs := []string{GIT}
+	if base1 == base2 {
+		cmdArgs = append(cmdArgs, "diff", colorOption, dl1.Commit, dl2.Commit, "--")
+	} else {
+		log.Notef("%spatch set %d and %d have different bases, show range-diff",
+			v.Prompt(), patch1, patch2)
+		cmdArgs = append(cmdArgs, "range-diff", colorOption,
+			base1+".."+dl1.Commit,
+			base2+".."+dl2.Commit)
+	}
+	log.Debugf("%swill execute: %s", v.Prompt(), strings.Join(cmdArgs, " "))
+	return executeCommandIn(v.WorkDir, cmdArgs)
+}
+
 // CherryPick runs cherry-pick on commits.
 func (v Project) CherryPick(commits ...string) error {
 	for i := len(commits) - 1; i >= 0; i-- {
@@ -105,3 +204,121 @@ func (v Project) Revert(commit string) error {
 	log.Debugf("%swill execute: %s", v.Prompt(), strings.Join(cmdArgs, " "))
 	return executeCommandIn(v.WorkDir, cmdArgs)
 }
+
+// reviewWorktreeLockReason is used to lock worktrees created for code
+// reviews, so that they are not pruned by git and can be found by cleanup.
+const reviewWorktreeLockReason = "git-repo download"
+
+// AddReviewWorktree creates a linked worktree at dir with the downloaded
+// patch set checked out as detached HEAD.
+func (v Project) AddReviewWorktree(dir string, dl *PatchSet, changeID string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("worktree '%s' already exists", dir)
+	}
+	result := v.ExecuteCommand(GIT, "worktree", "add", "--detach", dir, dl.Commit)
+	if !result.Success() {
+		return fmt.Errorf("fail to create worktree '%s': %s",
+			dir,
+			strings.TrimSpace(result.Stderr()))
+	}
+	result = v.ExecuteCommand(GIT, "worktree", "lock",
+		"--reason", reviewWorktreeLockReason+" "+changeID, dir)
+	if !result.Success() {
+		log.Warnf("%sfail to lock worktree '%s': %s",
+			v.Prompt(),
+			dir,
+			strings.TrimSpace(result.Stderr()))
+	}
+	return nil
+}
+
+// commonGitDir returns the git dir shared by all worktrees of project.
+func (v Project) commonGitDir() (string, error) {
+	result := v.ExecuteCommand(GIT, "rev-parse", "--git-common-dir")
+	if !result.Success() {
+		return "", fmt.Errorf("fail to get git common dir: %s",
+			strings.TrimSpace(result.Stderr()))
+	}
+	dir := strings.TrimSpace(result.Stdout())
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(v.WorkDir, dir)
+	}
+	return dir, nil
+}
+
+// ReviewWorktrees returns paths of worktrees created for code reviews.
+// The "locked" file of worktree is read directly, for "git worktree list
+// --porcelain" does not show lock reason before git 2.31.
+func (v Project) ReviewWorktrees() ([]string, error) {
+	commonDir, err := v.commonGitDir()
+	if err != nil {
+		return nil, err
+	}
+	lockFiles, err := filepath.Glob(filepath.Join(commonDir, "worktrees", "*", "locked"))
+	if err != nil {
+		return nil, err
+	}
+
+	worktrees := []string{}
+	for _, lockFile := range lockFiles {
+		reason, err := ioutil.ReadFile(lockFile)
+		if err != nil || !strings.HasPrefix(string(reason), reviewWorktreeLockReason) {
+			continue
+		}
+		gitdir, err := ioutil.ReadFile(filepath.Join(filepath.Dir(lockFile), "gitdir"))
+		if err != nil {
+			log.Debugf("%sfail to read gitdir of worktree '%s': %s",
+				v.Prompt(),
+				filepath.Dir(lockFile),
+				err)
+			continue
+		}
+		worktrees = append(worktrees, filepath.Dir(strings.TrimSpace(string(gitdir))))
+	}
+	sort.Strings(worktrees)
+	return worktrees, nil
+}
+
+// worktreeIsDirty checks whether worktree at dir has uncommitted changes or
+// untracked files.
+func (v Project) worktreeIsDirty(dir string) bool {
+	result := v.ExecuteCommand(GIT, "-C", dir, "status", "--porcelain")
+	return !result.Success() || strings.TrimSpace(result.Stdout()) != ""
+}
+
+// RemoveReviewWorktrees removes worktrees created for code reviews, and
+// returns paths of removed worktrees and paths of worktrees skipped for
+// uncommitted changes. Dirty worktrees are also removed if force is true.
+func (v Project) RemoveReviewWorktrees(force bool) ([]string, []string, error) {
+	worktrees, err := v.ReviewWorktrees()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed := []string{}
+	skipped := []string{}
+	for _, dir := range worktrees {
+		if _, err := os.Stat(dir); err == nil && !force && v.worktreeIsDirty(dir) {
+			skipped = append(skipped, dir)
+			continue
+		}
+		v.ExecuteCommand(GIT, "worktree", "unlock", dir)
+		args := []string{GIT, "worktree", "remove"}
+		if force {
+			args = append(args, "--force")
+		}
+		result := v.ExecuteCommand(append(args, dir)...)
+		if !result.Success() {
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				return removed, skipped, fmt.Errorf("fail to remove worktree '%s': %s",
+					dir,
+					strings.TrimSpace(result.Stderr()))
+			}
+		}
+		removed = append(removed, dir)
+	}
+	if len(removed) > 0 {
+		v.ExecuteCommand(GIT, "worktree", "prune")
+	}
+	return removed, skipped, nil
+}
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -8,10 +9,27 @@ const (
 	groupDefaultConst    = "default"
 	groupAllConst        = "all"
 	groupNotDefaultConst = "notdefault"
+
+	// groupsExprOperators are characters which turn on expression syntax.
+	groupsExprOperators = "&|!()"
 )
 
 // MatchGroups checks if project has matched groups.
+//
+// Match can be a comma separated list of groups, and a group with "-"
+// prefix excludes projects of the group, e.g.: "default,-platform-windows".
+// Match can also be a boolean expression, e.g.:
+// "(default|tools)&!platform-windows&team-camera". Returns false if match
+// is an invalid expression, use ValidateGroups to check it before match.
 func MatchGroups(match, groups string) bool {
+	if isGroupsExpr(match) {
+		expr, err := parseGroupsExpr(match)
+		if err != nil {
+			return false
+		}
+		return expr.match(projectGroupsSet(groups))
+	}
+
 	matchGroups := []string{}
 	for _, g := range strings.Split(match, ",") {
 		matchGroups = append(matchGroups, strings.TrimSpace(g))
@@ -51,3 +69,209 @@ func MatchGroups(match, groups string) bool {
 	return matched
 
 }
+
+// ValidateGroups checks syntax of match used in MatchGroups.
+func ValidateGroups(match string) error {
+	if !isGroupsExpr(match) {
+		return nil
+	}
+	_, err := parseGroupsExpr(match)
+	return err
+}
+
+func isGroupsExpr(match string) bool {
+	return strings.ContainsAny(match, groupsExprOperators)
+}
+
+// projectGroupsSet returns groups of a project, include implicit groups:
+// "all" and "default" (if project is not in "notdefault" group).
+func projectGroupsSet(groups string) map[string]bool {
+	result := map[string]bool{
+		groupAllConst: true,
+	}
+	for _, g := range strings.Split(groups, ",") {
+		g = strings.TrimSpace(g)
+		if g != "" {
+			result[g] = true
+		}
+	}
+	if !result[groupNotDefaultConst] {
+		result[groupDefaultConst] = true
+	}
+	return result
+}
+
+// groupsExpr is the parsed boolean expression of groups.
+type groupsExpr interface {
+	match(groups map[string]bool) bool
+}
+
+type groupsName string
+
+func (v groupsName) match(groups map[string]bool) bool {
+	return groups[string(v)]
+}
+
+type groupsNot struct {
+	expr groupsExpr
+}
+
+func (v groupsNot) match(groups map[string]bool) bool {
+	return !v.expr.match(groups)
+}
+
+type groupsAnd struct {
+	left, right groupsExpr
+}
+
+func (v groupsAnd) match(groups map[string]bool) bool {
+	return v.left.match(groups) && v.right.match(groups)
+}
+
+type groupsOr struct {
+	left, right groupsExpr
+}
+
+func (v groupsOr) match(groups map[string]bool) bool {
+	return v.left.match(groups) || v.right.match(groups)
+}
+
+// groupsExprParser is a recursive descent parser for groups expression:
+//
+//	list  := or ( "," or )*
+//	or    := and ( "|" and )*
+//	and   := unary ( "&" unary )*
+//	unary := "!" unary | "(" list ")" | name
+//
+// Comma has the lowest precedence, and works like "|".
+type groupsExprParser struct {
+	input string
+	pos   int
+}
+
+func parseGroupsExpr(input string) (groupsExpr, error) {
+	p := groupsExprParser{input: input}
+	expr, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := p.peek(); ok {
+		return nil, p.errorf("unexpected '%c'", c)
+	}
+	return expr, nil
+}
+
+func (v *groupsExprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid groups expression '%s': %s at position %d",
+		v.input,
+		fmt.Sprintf(format, args...),
+		v.pos+1)
+}
+
+// peek skips spaces and returns next character.
+func (v *groupsExprParser) peek() (byte, bool) {
+	for v.pos < len(v.input) && (v.input[v.pos] == ' ' || v.input[v.pos] == '\t') {
+		v.pos++
+	}
+	if v.pos >= len(v.input) {
+		return 0, false
+	}
+	return v.input[v.pos], true
+}
+
+func (v *groupsExprParser) parseList() (groupsExpr, error) {
+	left, err := v.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c, ok := v.peek()
+		if !ok || c != ',' {
+			return left, nil
+		}
+		v.pos++
+		right, err := v.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = groupsOr{left, right}
+	}
+}
+
+func (v *groupsExprParser) parseOr() (groupsExpr, error) {
+	left, err := v.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c, ok := v.peek()
+		if !ok || c != '|' {
+			return left, nil
+		}
+		v.pos++
+		right, err := v.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = groupsOr{left, right}
+	}
+}
+
+func (v *groupsExprParser) parseAnd() (groupsExpr, error) {
+	left, err := v.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c, ok := v.peek()
+		if !ok || c != '&' {
+			return left, nil
+		}
+		v.pos++
+		right, err := v.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = groupsAnd{left, right}
+	}
+}
+
+func (v *groupsExprParser) parseUnary() (groupsExpr, error) {
+	c, ok := v.peek()
+	if !ok {
+		return nil, v.errorf("missing group name")
+	}
+
+	switch c {
+	case '!':
+		v.pos++
+		expr, err := v.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return groupsNot{expr}, nil
+	case '(':
+		v.pos++
+		expr, err := v.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok = v.peek(); !ok || c != ')' {
+			return nil, v.errorf("missing ')'")
+		}
+		v.pos++
+		return expr, nil
+	case '-':
+		return nil, v.errorf("use '!' instead of '-' to exclude groups")
+	}
+
+	start := v.pos
+	for v.pos < len(v.input) &&
+		!strings.ContainsRune(groupsExprOperators+", \t", rune(v.input[v.pos])) {
+		v.pos++
+	}
+	if start == v.pos {
+		return nil, v.errorf("unexpected '%c'", c)
+	}
+	return groupsName(v.input[start:v.pos]), nil
+}
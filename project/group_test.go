@@ -57,3 +57,65 @@ func TestMatchGroups(t *testing.T) {
 	groups = "g1,notdefault"
 	assert.False(MatchGroups(match, groups))
 }
+
+func TestMatchGroupsExpression(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		match  string
+		groups string
+		expect bool
+	}{
+		{"(default|tools)&!platform-windows&team-camera", "team-camera", true},
+		{"(default|tools)&!platform-windows&team-camera", "team-camera,notdefault", false},
+		{"(default|tools)&!platform-windows&team-camera", "tools,team-camera,notdefault", true},
+		{"(default|tools)&!platform-windows&team-camera", "team-camera,platform-windows", false},
+		{"(default|tools)&!platform-windows&team-camera", "", false},
+		{"!notdefault", "", true},
+		{"!notdefault", "g1,notdefault", false},
+		{"!!g1", "g1", true},
+		{"all&!g1", "g2", true},
+		{"all&!g1", "g1", false},
+		{"g1|g2&g3", "g1", true},
+		{"(g1|g2)&g3", "g1", false},
+		{"g1 & ( g2 | g3 )", "g1,g3", true},
+		{"g1&g2,platform-linux", "platform-linux", true},
+		{"g1&g2,platform-linux", "g1", false},
+		{"(g1,g2)&g3", "g2,g3", true},
+	} {
+		assert.Nil(ValidateGroups(tc.match))
+		assert.Equal(tc.expect, MatchGroups(tc.match, tc.groups),
+			"match '%s' with groups '%s'", tc.match, tc.groups)
+	}
+}
+
+func TestValidateGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, tc := range []struct {
+		match  string
+		errMsg string
+	}{
+		{"", ""},
+		{"default,-g1,g2", ""},
+		{"(g1|g2)&!g3", ""},
+		{"(g1|g2", "invalid groups expression '(g1|g2': missing ')' at position 7"},
+		{"g1|g2)", "invalid groups expression 'g1|g2)': unexpected ')' at position 6"},
+		{"g1&", "invalid groups expression 'g1&': missing group name at position 4"},
+		{"g1&|g2", "invalid groups expression 'g1&|g2': unexpected '|' at position 4"},
+		{"()", "invalid groups expression '()': unexpected ')' at position 2"},
+		{"!", "invalid groups expression '!': missing group name at position 2"},
+		{"g1&-g2", "invalid groups expression 'g1&-g2': use '!' instead of '-' to exclude groups at position 4"},
+		{"g1 g2|g3", "invalid groups expression 'g1 g2|g3': unexpected 'g' at position 4"},
+	} {
+		err := ValidateGroups(tc.match)
+		if tc.errMsg == "" {
+			assert.Nil(err, "validate '%s'", tc.match)
+		} else if assert.NotNil(err, "validate '%s'", tc.match) {
+			assert.Equal(tc.errMsg, err.Error())
+		}
+		if err != nil {
+			assert.False(MatchGroups(tc.match, "all"))
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package project
+
+import (
+	"bufio"
+	"path"
+	"regexp"
+	"strings"
+
+	log "github.com/jiangxin/multi-log"
+)
+
+const (
+	ownersFile = "OWNERS"
+)
+
+var (
+	// codeOwnersFiles are locations of CODEOWNERS file, the first one found
+	// is used, same as GitHub and GitLab.
+	codeOwnersFiles = []string{
+		"CODEOWNERS",
+		".github/CODEOWNERS",
+		".gitlab/CODEOWNERS",
+		"docs/CODEOWNERS",
+	}
+)
+
+// codeOwnersRule is a rule in CODEOWNERS file.
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeOwnersPatternToRegexp converts pattern in CODEOWNERS file, which
+// follows the rules of gitignore, to regular expression.
+func codeOwnersPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// Pattern with a slash at the beginning or middle is relative to root.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	expr := "^"
+	if !anchored {
+		expr += "(.*/)?"
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr += "(.*/)?"
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr += ".*"
+			i++
+		case c == '*':
+			expr += "[^/]*"
+		case c == '?':
+			expr += "[^/]"
+		default:
+			expr += regexp.QuoteMeta(string(c))
+		}
+	}
+	// Pattern matches a file, or all files under a directory. Trailing
+	// "/*" only matches files in the directory, not in subdirectories.
+	if dirOnly {
+		expr += "/.*$"
+	} else if strings.HasSuffix(pattern, "/*") {
+		expr += "$"
+	} else {
+		expr += "(/.*)?$"
+	}
+	return regexp.Compile(expr)
+}
+
+// parseCodeOwners parses rules in CODEOWNERS file.
+func parseCodeOwners(data string) []codeOwnersRule {
+	rules := []codeOwnersRule{}
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Ignore comments and sections of GitLab.
+		if line == "" || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i > 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		re, err := codeOwnersPatternToRegexp(fields[0])
+		if err != nil {
+			log.Debugf("bad pattern '%s' in CODEOWNERS: %s", fields[0], err)
+			continue
+		}
+		rule := codeOwnersRule{pattern: re}
+		for _, owner := range fields[1:] {
+			rule.owners = append(rule.owners, strings.TrimPrefix(owner, "@"))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// matchCodeOwners returns owners of file. The last matching rule wins.
+func matchCodeOwners(rules []codeOwnersRule, file string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(file) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// parseOwners parses OWNERS file (in the format of Chromium) for file in
+// the same directory, and returns owners and whether to stop searching
+// OWNERS files in parent directories ("set noparent").
+//
+// Supported lines: email, "per-file <glob>=<email>,...", "set noparent",
+// and comments. Other lines, such as "*" and "file:", are ignored.
+func parseOwners(data, file string) ([]string, bool) {
+	owners := []string{}
+	perFileOwners := []string{}
+	noParent := false
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "set noparent" {
+			noParent = true
+			continue
+		}
+		if strings.HasPrefix(line, "per-file ") {
+			items := strings.SplitN(strings.TrimPrefix(line, "per-file "), "=", 2)
+			if len(items) != 2 {
+				continue
+			}
+			matched := false
+			for _, glob := range strings.Split(items[0], ",") {
+				if ok, _ := path.Match(strings.TrimSpace(glob), path.Base(file)); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+			for _, owner := range strings.Split(items[1], ",") {
+				owner = strings.TrimSpace(owner)
+				if owner == "set noparent" {
+					noParent = true
+				} else if owner != "" && owner != "*" {
+					perFileOwners = append(perFileOwners, owner)
+				}
+			}
+			continue
+		}
+		if strings.Contains(line, "@") && !strings.ContainsAny(line, " :=") {
+			owners = append(owners, line)
+		}
+	}
+	return append(perFileOwners, owners...), noParent
+}
+
+// catFile returns content of file in revision, or empty string if not
+// exist.
+func (v Project) catFile(revision, file string) string {
+	result := v.ExecuteCommand(GIT, "cat-file", "blob", revision+":"+file)
+	if !result.Success() {
+		return ""
+	}
+	return result.Stdout()
+}
+
+// ChangedFiles returns files touched by commits of branch.
+func (v ReviewableBranch) ChangedFiles() []string {
+	files := []string{}
+	args := []string{GIT, "log", "--format=", "--name-only", "--no-renames", v.Branch.Hash, "--not"}
+	if v.CodeReview.Empty() {
+		args = append(args, v.RemoteTrack.Track.Hash)
+	} else {
+		args = append(args, v.CodeReview.Ref)
+	}
+	result := v.Project.ExecuteCommand(args...)
+	if !result.Success() {
+		log.Debugf("%sfail to get changed files: %s", v.Project.Prompt(), result.Stderr())
+		return nil
+	}
+	found := make(map[string]bool)
+	for _, file := range strings.Split(result.Stdout(), "\n") {
+		file = strings.TrimSpace(file)
+		if file != "" && !found[file] {
+			found[file] = true
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// SuggestReviewers returns reviewers for files changed by the branch,
+// which are defined in CODEOWNERS or OWNERS files of the project. The
+// owners files are read from the branch to be uploaded, and current user
+// is not in the result.
+func (v ReviewableBranch) SuggestReviewers() []string {
+	p := v.Project
+	if p == nil {
+		return nil
+	}
+	files := v.ChangedFiles()
+	if len(files) == 0 {
+		return nil
+	}
+
+	reviewers := []string{}
+	found := make(map[string]bool)
+	me, myLogin := p.userIdentity()
+	add := func(owners []string) {
+		for _, owner := range owners {
+			if found[owner] {
+				continue
+			}
+			found[owner] = true
+			if me != "" && (owner == me || owner == myLogin) {
+				continue
+			}
+			reviewers = append(reviewers, owner)
+		}
+	}
+
+	// CODEOWNERS has higher priority than OWNERS.
+	for _, name := range codeOwnersFiles {
+		data := p.catFile(v.Branch.Hash, name)
+		if data == "" {
+			continue
+		}
+		rules := parseCodeOwners(data)
+		for _, file := range files {
+			add(matchCodeOwners(rules, file))
+		}
+		return reviewers
+	}
+
+	ownersCache := make(map[string]string)
+	for _, file := range files {
+		dir := path.Dir(file)
+		for {
+			ownersPath := path.Join(dir, ownersFile)
+			data, ok := ownersCache[ownersPath]
+			if !ok {
+				data = p.catFile(v.Branch.Hash, ownersPath)
+				ownersCache[ownersPath] = data
+			}
+			owners, noParent := parseOwners(data, file)
+			add(owners)
+			if noParent || dir == "." {
+				break
+			}
+			dir = path.Dir(dir)
+		}
+	}
+	return reviewers
+}
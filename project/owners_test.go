@@ -0,0 +1,69 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCodeOwners(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := parseCodeOwners(`# comments
+*                   @default
+*.go                @gopher # trailing comments
+/docs/              docs@example.com
+build/logs/         @logs
+apps/*              @apps
+**/test/**          @tester
+
+[Section]
+/README.md          readme@example.com
+`)
+	assert.Equal(7, len(rules))
+
+	assert.Equal([]string{"default"}, matchCodeOwners(rules, "Makefile"))
+	assert.Equal([]string{"gopher"}, matchCodeOwners(rules, "main.go"))
+	assert.Equal([]string{"gopher"}, matchCodeOwners(rules, "cmd/main.go"))
+	assert.Equal([]string{"docs@example.com"}, matchCodeOwners(rules, "docs/README"))
+	assert.Equal([]string{"docs@example.com"}, matchCodeOwners(rules, "docs/api/index.md"))
+	assert.Equal([]string{"default"}, matchCodeOwners(rules, "src/docs/README"))
+	assert.Equal([]string{"logs"}, matchCodeOwners(rules, "build/logs/a.log"))
+	assert.Equal([]string{"default"}, matchCodeOwners(rules, "src/build/logs/a.log"))
+	assert.Equal([]string{"apps"}, matchCodeOwners(rules, "apps/main.c"))
+	assert.Equal([]string{"default"}, matchCodeOwners(rules, "apps/app1/main.c"))
+	assert.Equal([]string{"tester"}, matchCodeOwners(rules, "test/a.go"))
+	assert.Equal([]string{"tester"}, matchCodeOwners(rules, "src/test/a.go"))
+	assert.Equal([]string{"readme@example.com"}, matchCodeOwners(rules, "README.md"))
+	assert.Nil(matchCodeOwners(nil, "README.md"))
+}
+
+func TestParseOwners(t *testing.T) {
+	assert := assert.New(t)
+
+	data := `# comments
+set noparent
+a@example.com
+b@example.com  # trailing comments
+*
+file://build/OWNERS
+per-file *.c,*.h=c@example.com, d@example.com
+per-file *.go=go@example.com
+`
+	owners, noParent := parseOwners(data, "src/main.c")
+	assert.True(noParent)
+	assert.Equal([]string{
+		"c@example.com",
+		"d@example.com",
+		"a@example.com",
+		"b@example.com",
+	}, owners)
+
+	owners, noParent = parseOwners("a@example.com\nper-file *.go=set noparent\n", "main.go")
+	assert.True(noParent)
+	assert.Equal([]string{"a@example.com"}, owners)
+
+	owners, noParent = parseOwners("", "main.go")
+	assert.False(noParent)
+	assert.Equal([]string{}, owners)
+}
@@ -2,6 +2,7 @@ package project
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -278,6 +279,41 @@ func (v Repository) Revlist(args ...string) ([]string, error) {
 	return result, nil
 }
 
+// GitLinks returns commits of gitlinks (submodules) in the tree of revision,
+// indexed by path.
+func (v Repository) GitLinks(revision string) (map[string]string, error) {
+	result := make(map[string]string)
+	cmdArgs := []string{
+		"git",
+		"ls-tree",
+		"-r",
+		"-z",
+		revision,
+	}
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Dir = v.RepoDir()
+	cmd.Stdin = nil
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("fail to list tree of '%s' in %s: %s", revision, v.Name, err)
+	}
+
+	// Each entry has format: "<mode> SP <type> SP <object> TAB <file> NUL"
+	for _, entry := range strings.Split(string(out), "\x00") {
+		items := strings.SplitN(entry, "\t", 2)
+		if len(items) != 2 {
+			continue
+		}
+		fields := strings.Fields(items[0])
+		if len(fields) != 3 || fields[1] != "commit" {
+			continue
+		}
+		result[items[1]] = fields[2]
+	}
+	return result, nil
+}
+
 // Raw returns go-git repository object.
 func (v Repository) Raw() *git.Repository {
 	var (
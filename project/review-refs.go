@@ -0,0 +1,173 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
+	log "github.com/jiangxin/multi-log"
+)
+
+// ReviewRef returns local reference of patch set of code review, which is
+// fetched by sync for offline review.
+func ReviewRef(id, patch string) string {
+	if patch == "" || patch == "0" {
+		patch = "head"
+	}
+	return config.RefsReviews + id + "/" + patch
+}
+
+// fetchReviewRefs fetches review references using refspecs from remote.
+func (v Project) fetchReviewRefs(remoteName string, options []string, refSpecs ...string) error {
+	cmdArgs := []string{GIT, "fetch", "--quiet", "--no-tags"}
+	for _, option := range options {
+		cmdArgs = append(cmdArgs, "-o", option)
+	}
+	cmdArgs = append(cmdArgs, remoteName)
+	cmdArgs = append(cmdArgs, refSpecs...)
+	log.Debugf("%swill execute: %s", v.Prompt(), strings.Join(cmdArgs, " "))
+	result := v.ExecuteCommand(cmdArgs...)
+	if !result.Success() {
+		return fmt.Errorf("fail to fetch code reviews: %s", strings.TrimSpace(result.Stderr()))
+	}
+	return nil
+}
+
+// ReviewRefs returns local references of code reviews fetched by sync.
+func (v Project) ReviewRefs() []string {
+	result := v.ExecuteCommand(GIT, "for-each-ref", "--format=%(refname)", config.RefsReviews)
+	if !result.Success() {
+		return nil
+	}
+	return strings.Fields(result.Stdout())
+}
+
+// FetchReviews fetches patch sets of code reviews from remote into local
+// references "refs/reviews/<id>/<patch>". Patch sets already fetched are
+// skipped. Returns number of fetched code reviews.
+func (v Project) FetchReviews(remote *Remote, reviews []helper.ReviewInfo) (int, error) {
+	var (
+		refSpecs []string
+		fetched  int
+		errs     []string
+	)
+
+	if remote == nil || !remote.ProtoHelperReady() {
+		return 0, fmt.Errorf("no remote to fetch code reviews")
+	}
+
+	for _, review := range reviews {
+		localRef := ReviewRef(review.ID, review.Patch)
+		if _, err := v.ResolveRevision(localRef); err == nil {
+			continue
+		}
+		ref, options, err := remote.GetDownloadRefOptions(review.ID, review.Patch)
+		if err != nil || ref == "" {
+			ref, options = review.Ref, nil
+		}
+		if ref == "" {
+			log.Debugf("%sno reference for code review #%s", v.Prompt(), review.ID)
+			continue
+		}
+		refSpec := "+" + ref + ":" + localRef
+		// Fetch options are specific for each code review.
+		if len(options) > 0 {
+			err = v.fetchReviewRefs(remote.Name, options, refSpec)
+			if err != nil {
+				errs = append(errs, err.Error())
+			} else {
+				fetched++
+			}
+			continue
+		}
+		refSpecs = append(refSpecs, refSpec)
+	}
+	if len(refSpecs) > 0 {
+		err := v.fetchReviewRefs(remote.Name, nil, refSpecs...)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			fetched += len(refSpecs)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fetched, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return fetched, nil
+}
+
+// PruneReviews removes local references of code reviews which are not in
+// reviews, and are closed (e.g. merged or abandoned) as closed reports.
+// Returns number of pruned code reviews.
+func (v Project) PruneReviews(reviews []helper.ReviewInfo, closed func(id string) bool) (int, error) {
+	var (
+		errs    []string
+		keep    = make(map[string]bool)
+		removed = make(map[string]bool)
+	)
+
+	for _, review := range reviews {
+		keep[review.ID] = true
+	}
+	for _, ref := range v.ReviewRefs() {
+		id := strings.Split(strings.TrimPrefix(ref, config.RefsReviews), "/")[0]
+		if keep[id] {
+			continue
+		}
+		if !removed[id] && !closed(id) {
+			keep[id] = true
+			continue
+		}
+		result := v.ExecuteCommand(GIT, "update-ref", "-d", ref)
+		if !result.Success() {
+			errs = append(errs, fmt.Sprintf("fail to remove '%s': %s",
+				ref,
+				strings.TrimSpace(result.Stderr())))
+			continue
+		}
+		removed[id] = true
+	}
+
+	if len(errs) > 0 {
+		return len(removed), fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return len(removed), nil
+}
+
+// LocalReviewRef returns local reference of patch set of code review
+// fetched by sync, or the latest patch set if patchID is 0. Returns empty
+// string if not found.
+func (v Project) LocalReviewRef(reviewID, patchID int) string {
+	id := strconv.Itoa(reviewID)
+	if patchID > 0 {
+		ref := ReviewRef(id, strconv.Itoa(patchID))
+		if _, err := v.ResolveRevision(ref); err == nil {
+			return ref
+		}
+		return ""
+	}
+
+	patches := []int{}
+	head := ""
+	prefix := config.RefsReviews + id + "/"
+	for _, ref := range v.ReviewRefs() {
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		patch := strings.TrimPrefix(ref, prefix)
+		if n, err := strconv.Atoi(patch); err == nil {
+			patches = append(patches, n)
+		} else if patch == "head" {
+			head = ref
+		}
+	}
+	if len(patches) > 0 {
+		sort.Ints(patches)
+		return ReviewRef(id, strconv.Itoa(patches[len(patches)-1]))
+	}
+	return head
+}
@@ -0,0 +1,117 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
+	"github.com/jiangxin/goconfig"
+)
+
+const (
+	// reviewAliasPrefix is prefix of git config to define reviewer
+	// aliases, e.g.: "review.alias.camera = a@example.com, b@example.com".
+	reviewAliasPrefix = "review.alias."
+
+	// reviewAliasesFile is file in manifest project to define reviewer
+	// aliases shared by team, in the same format of git config.
+	reviewAliasesFile = "review-aliases"
+)
+
+// userIdentity returns email and login name of current user.
+func (v Project) userIdentity() (string, string) {
+	m := helper.UserEmailPattern.FindStringSubmatch(v.UserEmail())
+	if m == nil {
+		return "", ""
+	}
+	return m[2] + "@" + m[3], m[2]
+}
+
+// addReviewerAliases adds aliases defined in cfg, and aliases already
+// defined in aliases have higher priority.
+func addReviewerAliases(aliases map[string][]string, cfg goconfig.GitConfig) {
+	if cfg == nil {
+		return
+	}
+	for _, key := range cfg.Keys() {
+		if !strings.HasPrefix(key, reviewAliasPrefix) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(key, reviewAliasPrefix))
+		if _, ok := aliases[name]; ok || name == "" {
+			continue
+		}
+		members := []string{}
+		for _, value := range cfg.GetAll(key) {
+			for _, member := range strings.Split(value, ",") {
+				member = strings.TrimSpace(member)
+				if member != "" {
+					members = append(members, member)
+				}
+			}
+		}
+		aliases[name] = members
+	}
+}
+
+// ReviewerAliases returns reviewer aliases defined in git config of the
+// project, git config of the manifest project, and the file
+// "review-aliases" in the manifest project, in order of priority.
+func (v Project) ReviewerAliases() map[string][]string {
+	aliases := make(map[string][]string)
+
+	addReviewerAliases(aliases, v.Config())
+	if v.Settings == nil || v.IsMetaProject() {
+		return aliases
+	}
+	addReviewerAliases(aliases, v.Settings.Config)
+	if v.Settings.TopDir != "" {
+		file := filepath.Join(v.Settings.TopDir, config.DotRepo, config.Manifests, reviewAliasesFile)
+		cfg, err := goconfig.Load(file)
+		if err == nil {
+			addReviewerAliases(aliases, cfg)
+		}
+	}
+	return aliases
+}
+
+// expandReviewers expands reviewer aliases recursively, and returns error
+// if aliases are defined in a cycle.
+func expandReviewers(reviewers []string, aliases map[string][]string) ([]string, error) {
+	var (
+		result = []string{}
+		expand func(string, []string) error
+	)
+
+	expand = func(reviewer string, path []string) error {
+		name := strings.ToLower(reviewer)
+		members, ok := aliases[name]
+		if !ok {
+			result = append(result, reviewer)
+			return nil
+		}
+		for _, p := range path {
+			if p == name {
+				return fmt.Errorf("cycle in reviewer aliases: %s -> %s",
+					strings.Join(path, " -> "),
+					name)
+			}
+		}
+		path = append(path[:len(path):len(path)], name)
+		for _, member := range members {
+			if err := expand(member, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, reviewer := range reviewers {
+		if err := expand(reviewer, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
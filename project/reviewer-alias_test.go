@@ -0,0 +1,60 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/jiangxin/goconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandReviewers(t *testing.T) {
+	assert := assert.New(t)
+
+	aliases := map[string][]string{
+		"camera": {"a@example.com", "isp"},
+		"isp":    {"b@example.com", "c@example.com"},
+		"x":      {"y"},
+		"y":      {"z"},
+		"z":      {"x"},
+	}
+
+	reviewers, err := expandReviewers([]string{"Camera", "d@example.com"}, aliases)
+	assert.Nil(err)
+	assert.Equal([]string{
+		"a@example.com",
+		"b@example.com",
+		"c@example.com",
+		"d@example.com",
+	}, reviewers)
+
+	reviewers, err = expandReviewers([]string{"isp", "isp"}, aliases)
+	assert.Nil(err)
+	assert.Equal([]string{
+		"b@example.com",
+		"c@example.com",
+		"b@example.com",
+		"c@example.com",
+	}, reviewers)
+
+	_, err = expandReviewers([]string{"camera", "y"}, aliases)
+	assert.Equal("cycle in reviewer aliases: y -> z -> x -> y", err.Error())
+}
+
+func TestAddReviewerAliases(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := goconfig.NewGitConfig()
+	cfg.Set("review.alias.camera", "a@example.com, b@example.com")
+	cfg.Add("review.alias.camera", "c@example.com")
+	cfg.Set("review.alias.isp", "d@example.com")
+	cfg.Set("review.https://example.com.autoreviewer", "e@example.com")
+
+	aliases := map[string][]string{
+		"isp": {"f@example.com"},
+	}
+	addReviewerAliases(aliases, cfg)
+	assert.Equal(map[string][]string{
+		"camera": {"a@example.com", "b@example.com", "c@example.com"},
+		"isp":    {"f@example.com"},
+	}, aliases)
+}
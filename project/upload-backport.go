@@ -0,0 +1,96 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+)
+
+// cherryPickInWorktree cherry-picks commits onto base in a temporary
+// worktree, and returns the new commit. The worktree of user is not touched.
+func (v Project) cherryPickInWorktree(base string, commits []string) (string, error) {
+	dir, err := ioutil.TempDir("", "git-repo-backport-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		result := v.ExecuteCommand(GIT, "worktree", "remove", "--force", dir)
+		if !result.Success() {
+			log.Debugf("%sfail to remove worktree '%s': %s", v.Prompt(), dir, result.Stderr())
+		}
+		os.RemoveAll(dir)
+		v.ExecuteCommand(GIT, "worktree", "prune")
+	}()
+
+	result := v.ExecuteCommand(GIT, "worktree", "add", "--detach", dir, base)
+	if !result.Success() {
+		return "", fmt.Errorf("fail to create worktree: %s", strings.TrimSpace(result.Stderr()))
+	}
+	for _, commit := range commits {
+		result = v.ExecuteCommand(GIT, "-C", dir, "cherry-pick", "-x", "--allow-empty", commit)
+		if !result.Success() {
+			v.ExecuteCommand(GIT, "-C", dir, "cherry-pick", "--abort")
+			return "", fmt.Errorf("conflict when cherry-picking %.7s onto %s", commit, base)
+		}
+	}
+	result = v.ExecuteCommand(GIT, "-C", dir, "rev-parse", "HEAD")
+	if !result.Success() {
+		return "", fmt.Errorf("fail to resolve HEAD of worktree: %s", strings.TrimSpace(result.Stderr()))
+	}
+	return strings.TrimSpace(result.Stdout()), nil
+}
+
+// UploadBackport cherry-picks commits of branch onto the remote tracking
+// branch of dest, and sends them as a new code review for dest. Code
+// reviews created are appended to v.Reviews.
+func (v *ReviewableBranch) UploadBackport(o config.UploadOptions, dest string) error {
+	p := v.Project
+	if !v.CodeReview.Empty() {
+		return fmt.Errorf("cannot backport when updating code review #%s", v.CodeReview.ID)
+	}
+	if v.Remote == nil {
+		return fmt.Errorf("no remote for branch '%s'", v.Branch.Name)
+	}
+
+	track := p.RemoteMatchingBranch(v.Remote.Name, dest)
+	base, err := p.ResolveRevision(track)
+	if err != nil {
+		return fmt.Errorf("cannot find remote branch '%s'", track)
+	}
+
+	// Cherry-pick from the oldest commit.
+	commits := v.Commits()
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits for review")
+	}
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	commit, err := p.cherryPickInWorktree(base, commits)
+	if err != nil {
+		return err
+	}
+
+	// Backport is always a new code review, never update the code review
+	// of the branch.
+	o.CodeReview = config.CodeReview{}
+	o.DestBranch = dest
+	o.Commit = commit
+	o.OldOid = ""
+	o.Stacked = false
+	err = v.prepareUpload(&o)
+	if err != nil {
+		return err
+	}
+	v.Remote.GetCapabilities().CheckUploadOptions(&o, v.Remote.GetType())
+	output, err := v.gitPush(&o)
+	if err != nil {
+		return err
+	}
+	v.Reviews = append(v.Reviews, v.Remote.ParseReviewResults(output)...)
+	return nil
+}
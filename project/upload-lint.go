@@ -0,0 +1,241 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+)
+
+// Levels of lint rules.
+const (
+	LintOff     = "off"
+	LintWarning = "warning"
+	LintError   = "error"
+)
+
+var (
+	reChangeID = regexp.MustCompile(`(?m)^Change-Id:\s*I[0-9a-f]{40}\s*$`)
+	reSignOff  = regexp.MustCompile(`(?m)^Signed-off-by:\s*\S`)
+)
+
+// LintOptions defines rules of lint for commits before upload, which are
+// read from git config "upload.lint.*".
+type LintOptions struct {
+	ChangeID         string // Level of missing Change-Id.
+	SubjectLength    string // Level of too long subject.
+	MaxSubjectLength int
+	SignOff          string // Level of missing Signed-off-by.
+	LargeFile        string // Level of large files.
+	MaxFileSize      int64
+	BinaryFile       string // Level of binary files.
+}
+
+// LintResult is a problem found in a commit.
+type LintResult struct {
+	Level   string
+	Commit  string
+	Message string
+}
+
+func (v LintResult) String() string {
+	commit := v.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return fmt.Sprintf("%s: %s: %s", strings.ToUpper(v.Level), commit, v.Message)
+}
+
+// lintLevel returns level of rule, or default level if not set or invalid.
+func lintLevel(cfg ConfigWithDefault, key, defaultLevel string) string {
+	level := strings.ToLower(cfg.Get(key))
+	switch level {
+	case "":
+		return defaultLevel
+	case LintError, LintWarning, LintOff:
+		return level
+	case "warn":
+		return LintWarning
+	case "no", "false", "none":
+		return LintOff
+	}
+	log.Warnf("bad value of %s: %s, should be error, warning or off", key, level)
+	return defaultLevel
+}
+
+// NewLintOptions reads lint options of project from git config:
+//
+//	upload.lint.changeId         : missing Change-Id (default: error for
+//	                               Gerrit, otherwise off)
+//	upload.lint.subjectLength    : too long subject (default: warning)
+//	upload.lint.maxSubjectLength : max length of subject (default: 72)
+//	upload.lint.signOff          : missing Signed-off-by (default: off)
+//	upload.lint.largeFile        : too large file (default: error)
+//	upload.lint.maxFileSize      : max size of file (default: 10m)
+//	upload.lint.binaryFile       : binary file (default: warning)
+//
+// Levels of rules are error, warning and off.
+func NewLintOptions(p *Project, gerrit bool) *LintOptions {
+	cfg := p.ConfigWithDefault()
+	o := LintOptions{}
+
+	if gerrit {
+		o.ChangeID = lintLevel(cfg, "upload.lint.changeId", LintError)
+	} else {
+		o.ChangeID = lintLevel(cfg, "upload.lint.changeId", LintOff)
+	}
+	o.SubjectLength = lintLevel(cfg, "upload.lint.subjectLength", LintWarning)
+	o.MaxSubjectLength = 72
+	if value := cfg.Get("upload.lint.maxSubjectLength"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Warnf("bad value of upload.lint.maxSubjectLength: %s", value)
+		} else {
+			o.MaxSubjectLength = n
+		}
+	}
+	o.SignOff = lintLevel(cfg, "upload.lint.signOff", LintOff)
+	o.LargeFile = lintLevel(cfg, "upload.lint.largeFile", LintError)
+	o.MaxFileSize = 10 << 20
+	if value := cfg.Get("upload.lint.maxFileSize"); value != "" {
+		size, err := config.ParseSize(value)
+		if err != nil || size <= 0 {
+			log.Warnf("bad value of upload.lint.maxFileSize: %s", value)
+		} else {
+			o.MaxFileSize = size
+		}
+	}
+	o.BinaryFile = lintLevel(cfg, "upload.lint.binaryFile", LintWarning)
+	return &o
+}
+
+// lintMessage checks commit message.
+func (v LintOptions) lintMessage(commit, message string) []LintResult {
+	results := []LintResult{}
+	subject := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+
+	if v.ChangeID != LintOff && !reChangeID.MatchString(message) {
+		results = append(results, LintResult{
+			Level:   v.ChangeID,
+			Commit:  commit,
+			Message: "missing Change-Id in commit message",
+		})
+	}
+	if v.SubjectLength != LintOff && len([]rune(subject)) > v.MaxSubjectLength {
+		results = append(results, LintResult{
+			Level:  v.SubjectLength,
+			Commit: commit,
+			Message: fmt.Sprintf("subject is too long (%d > %d)",
+				len([]rune(subject)),
+				v.MaxSubjectLength),
+		})
+	}
+	if v.SignOff != LintOff && !reSignOff.MatchString(message) {
+		results = append(results, LintResult{
+			Level:   v.SignOff,
+			Commit:  commit,
+			Message: "missing Signed-off-by in commit message",
+		})
+	}
+	return results
+}
+
+// lintFiles checks files added or modified by commit.
+func (v LintOptions) lintFiles(p *Project, commit string) []LintResult {
+	results := []LintResult{}
+
+	if v.BinaryFile != LintOff {
+		// Binary files are shown as "-\t-\t<file>" by --numstat.
+		result := p.ExecuteCommand(GIT, "diff-tree", "--no-commit-id", "-r", "--root",
+			"--no-renames", "--numstat", commit)
+		if !result.Success() {
+			log.Debugf("%sfail to get numstat of %s: %s", p.Prompt(), commit, result.Stderr())
+		}
+		for _, line := range strings.Split(result.Stdout(), "\n") {
+			items := strings.SplitN(line, "\t", 3)
+			if len(items) == 3 && items[0] == "-" && items[1] == "-" {
+				results = append(results, LintResult{
+					Level:   v.BinaryFile,
+					Commit:  commit,
+					Message: fmt.Sprintf("binary file '%s'", items[2]),
+				})
+			}
+		}
+	}
+
+	if v.LargeFile != LintOff {
+		// Raw output: ":<mode> <mode> <oid> <oid> <status>\t<file>".
+		result := p.ExecuteCommand(GIT, "diff-tree", "--no-commit-id", "-r", "--root",
+			"--no-renames", "--diff-filter=AM", commit)
+		if !result.Success() {
+			log.Debugf("%sfail to get files of %s: %s", p.Prompt(), commit, result.Stderr())
+		}
+		for _, line := range strings.Split(result.Stdout(), "\n") {
+			items := strings.SplitN(line, "\t", 2)
+			if len(items) != 2 {
+				continue
+			}
+			fields := strings.Fields(items[0])
+			if len(fields) < 4 {
+				continue
+			}
+			// Skip gitlinks (submodules).
+			if fields[1] == "160000" {
+				continue
+			}
+			size := p.ExecuteCommand(GIT, "cat-file", "-s", fields[3])
+			if !size.Success() {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(size.Stdout()), 10, 64)
+			if err == nil && n > v.MaxFileSize {
+				results = append(results, LintResult{
+					Level:  v.LargeFile,
+					Commit: commit,
+					Message: fmt.Sprintf("file '%s' is too large (%d > %d bytes)",
+						items[1],
+						n,
+						v.MaxFileSize),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// Lint checks commits of branch before upload.
+func (v ReviewableBranch) Lint(o *LintOptions) []LintResult {
+	results := []LintResult{}
+	p := v.Project
+	if p == nil || o == nil {
+		return results
+	}
+
+	commits := v.Commits()
+	// Check from the oldest commit.
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		result := p.ExecuteCommand(GIT, "log", "-1", "--format=%B", commit)
+		if result.Success() {
+			results = append(results, o.lintMessage(commit, result.Stdout())...)
+		} else {
+			log.Debugf("%sfail to read message of %s: %s", p.Prompt(), commit, result.Stderr())
+		}
+		results = append(results, o.lintFiles(p, commit)...)
+	}
+	return results
+}
+
+// LintErrors counts errors in lint results.
+func LintErrors(results []LintResult) int {
+	count := 0
+	for _, r := range results {
+		if r.Level == LintError {
+			count++
+		}
+	}
+	return count
+}
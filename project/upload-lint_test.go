@@ -0,0 +1,48 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	o := LintOptions{
+		ChangeID:         LintError,
+		SubjectLength:    LintWarning,
+		MaxSubjectLength: 20,
+		SignOff:          LintError,
+	}
+
+	results := o.lintMessage("1234567890", `topic: short subject
+
+Change-Id: I0123456789abcdef0123456789abcdef01234567
+Signed-off-by: Jiang Xin <worldhello.net@gmail.com>
+`)
+	assert.Equal([]LintResult{}, results)
+
+	results = o.lintMessage("1234567890", `topic: this subject is too long
+
+Change-Id: I0123
+`)
+	assert.Equal(2, LintErrors(results))
+	assert.Equal([]string{
+		"ERROR: 1234567: missing Change-Id in commit message",
+		"WARNING: 1234567: subject is too long (31 > 20)",
+		"ERROR: 1234567: missing Signed-off-by in commit message",
+	}, []string{
+		results[0].String(),
+		results[1].String(),
+		results[2].String(),
+	})
+
+	o = LintOptions{
+		ChangeID:      LintOff,
+		SubjectLength: LintOff,
+		SignOff:       LintOff,
+	}
+	results = o.lintMessage("1234567890", "topic: this subject is too long\n")
+	assert.Equal([]LintResult{}, results)
+}
@@ -0,0 +1,110 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	log "github.com/jiangxin/multi-log"
+)
+
+// fetchBranch fetches branch from remote, and updates the remote tracking
+// branch. Returns the name of the remote tracking branch.
+func (v Project) fetchBranch(remote, branch string) (string, error) {
+	branch = strings.TrimPrefix(branch, config.RefsHeads)
+	track := v.RemoteMatchingBranch(remote, branch)
+	result := v.ExecuteCommand(GIT, "fetch", "--quiet", remote,
+		fmt.Sprintf("+%s%s:%s", config.RefsHeads, branch, track))
+	if !result.Success() {
+		return "", fmt.Errorf("fail to fetch '%s' from '%s': %s",
+			branch,
+			remote,
+			strings.TrimSpace(result.Stderr()))
+	}
+	return track, nil
+}
+
+// rebaseIn runs git rebase in dir, and aborts rebase on conflict.
+func (v Project) rebaseIn(dir, upstream string) error {
+	result := v.ExecuteCommand(GIT, "-C", dir, "rebase", "--quiet", upstream)
+	if !result.Success() {
+		v.ExecuteCommand(GIT, "-C", dir, "rebase", "--abort")
+		return fmt.Errorf("conflict when rebasing onto '%s', rebase aborted", upstream)
+	}
+	return nil
+}
+
+// Rebase fetches upstream branch from remote, rebases the branch onto it,
+// and returns the reviewable branch with commits recomputed. The branch is
+// rebased in a temporary worktree if it is not the current branch, so that
+// the worktree of user is not touched.
+func (v ReviewableBranch) Rebase(upstream string) (*ReviewableBranch, error) {
+	p := v.Project
+	if !v.CodeReview.Empty() {
+		return nil, fmt.Errorf("cannot rebase when updating code review #%s", v.CodeReview.ID)
+	}
+	if v.Remote == nil {
+		return nil, fmt.Errorf("no remote for branch '%s'", v.Branch.Name)
+	}
+	if upstream == "" {
+		upstream = v.RemoteTrack.Branch
+	}
+	if upstream == "" {
+		return nil, fmt.Errorf("no upstream branch to rebase onto")
+	}
+
+	if config.IsDryRun() {
+		log.Notef("%swill rebase '%s' onto '%s'",
+			p.Prompt(),
+			v.Branch.Name,
+			p.RemoteMatchingBranch(v.Remote.Name, strings.TrimPrefix(upstream, config.RefsHeads)))
+		return &v, nil
+	}
+
+	track, err := p.fetchBranch(v.Remote.Name, upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	result := p.ExecuteCommand(GIT, "merge-base", "--is-ancestor", track, v.Branch.Hash)
+	if result.Success() {
+		log.Debugf("%sbranch '%s' is up to date with '%s'", p.Prompt(), v.Branch.Name, track)
+	} else if strings.TrimPrefix(p.GetHead(), config.RefsHeads) == v.Branch.Name {
+		if !p.IsClean() {
+			return nil, fmt.Errorf("cannot rebase branch '%s' with uncommitted changes",
+				v.Branch.Name)
+		}
+		log.Notef("%srebasing '%s' onto '%s'", p.Prompt(), v.Branch.Name, track)
+		err = p.rebaseIn(p.WorkDir, track)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		dir, err := ioutil.TempDir("", "git-repo-rebase-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			p.ExecuteCommand(GIT, "worktree", "remove", "--force", dir)
+			os.RemoveAll(dir)
+			p.ExecuteCommand(GIT, "worktree", "prune")
+		}()
+		result = p.ExecuteCommand(GIT, "worktree", "add", dir, v.Branch.Name)
+		if !result.Success() {
+			return nil, fmt.Errorf("fail to create worktree: %s", strings.TrimSpace(result.Stderr()))
+		}
+		log.Notef("%srebasing '%s' onto '%s'", p.Prompt(), v.Branch.Name, track)
+		err = p.rebaseIn(dir, track)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rb := p.GetUploadableBranch(v.Branch.Name, v.Remote, upstream, true)
+	if rb == nil {
+		return nil, fmt.Errorf("no commits for review after rebase")
+	}
+	return rb, nil
+}
@@ -0,0 +1,197 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/helper"
+	log "github.com/jiangxin/multi-log"
+)
+
+const (
+	// Commit message may have this trailer to set code review of commit.
+	reviewIDTrailer = "Review-Id"
+
+	// Keys in notes of commit, which are saved after stacked upload.
+	reviewNoteID     = "Review-Id"
+	reviewNoteURL    = "Review-URL"
+	reviewNoteCommit = "Commit"
+)
+
+// StackedReview is code review of a commit in stacked upload.
+type StackedReview struct {
+	helper.ReviewResult
+
+	// Commit is the commit last uploaded to the code review, and is used
+	// as old-oid when updating the code review.
+	Commit string
+}
+
+// parseReviewNote parses lines of "key: value" in message. Notes may be
+// concatenated when rewriting commits, and the last value wins.
+func parseReviewNote(message string) map[string]string {
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	for scanner.Scan() {
+		items := strings.SplitN(scanner.Text(), ":", 2)
+		if len(items) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(items[0]))
+		value := strings.TrimSpace(items[1])
+		if key != "" && value != "" {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// CommitReview returns code review of commit. Trailer "Review-Id" in
+// commit message has higher priority than notes saved in last upload.
+func (v Project) CommitReview(commit string) StackedReview {
+	review := StackedReview{}
+
+	result := v.ExecuteCommand(GIT, "notes", "--ref", config.RefsNotesReviews, "show", commit)
+	if result.Success() {
+		notes := parseReviewNote(result.Stdout())
+		review.ID = notes[strings.ToLower(reviewNoteID)]
+		review.URL = notes[strings.ToLower(reviewNoteURL)]
+		review.Commit = notes[strings.ToLower(reviewNoteCommit)]
+	}
+
+	result = v.ExecuteCommand(GIT, "log", "-1", "--format=%b", commit)
+	if result.Success() {
+		trailers := parseReviewNote(result.Stdout())
+		if id := trailers[strings.ToLower(reviewIDTrailer)]; id != "" && id != review.ID {
+			review = StackedReview{}
+			review.ID = id
+		}
+	}
+	return review
+}
+
+// SetCommitReview saves code review of commit in notes. Notes are copied
+// to new commit by "git commit --amend" and "git rebase", so the code
+// review can be updated after commits are rewritten.
+func (v *Project) SetCommitReview(commit string, review helper.ReviewResult) error {
+	if config.IsDryRun() {
+		return nil
+	}
+
+	cfg := v.Config()
+	found := false
+	for _, ref := range cfg.GetAll("notes.rewriteRef") {
+		if ref == config.RefsNotesReviews {
+			found = true
+			break
+		}
+	}
+	if !found {
+		cfg.Add("notes.rewriteRef", config.RefsNotesReviews)
+		err := v.SaveConfig(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	message := fmt.Sprintf("%s: %s\n", reviewNoteID, review.ID)
+	if review.URL != "" {
+		message += fmt.Sprintf("%s: %s\n", reviewNoteURL, review.URL)
+	}
+	message += fmt.Sprintf("%s: %s\n", reviewNoteCommit, commit)
+	result := v.ExecuteCommand(GIT,
+		"notes",
+		"--ref",
+		config.RefsNotesReviews,
+		"add",
+		"-f",
+		"-m",
+		message,
+		commit)
+	if !result.Success() {
+		return fmt.Errorf("fail to save notes: %s", strings.TrimSpace(result.Stderr()))
+	}
+	return nil
+}
+
+// uploadStacked uploads each commit of branch as its own code review,
+// which depends on code review of its parent commit. Code review of a
+// commit uploaded before is updated, and the commit last uploaded is sent
+// as old-oid.
+func (v *ReviewableBranch) uploadStacked(o *config.UploadOptions) error {
+	p := v.Project
+	if !v.CodeReview.Empty() {
+		return fmt.Errorf("cannot upload stacked reviews to code review #%s",
+			v.CodeReview.ID)
+	}
+
+	commits := v.Commits()
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits for review")
+	}
+	session := o.Topic
+	if session == "" {
+		session = strings.TrimPrefix(v.Branch.Name, config.RefsHeads)
+	}
+
+	v.Reviews = nil
+	// Upload from the oldest commit.
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		review := p.CommitReview(commit)
+
+		opts := *o
+		opts.Commit = commit
+		opts.OldOid = ""
+		// Title and description are for the whole branch, use commit
+		// message for each review instead.
+		opts.Title = ""
+		opts.Description = ""
+		if review.ID != "" {
+			if review.Commit == commit {
+				log.Notef("%scommit %s is not changed, skip updating review #%s",
+					p.Prompt(),
+					commit[:7],
+					review.ID)
+				v.Reviews = append(v.Reviews, review.ReviewResult)
+				continue
+			}
+			ref, _, err := v.Remote.GetDownloadRefOptions(review.ID, "")
+			if err != nil {
+				return err
+			}
+			opts.CodeReview = config.CodeReview{ID: review.ID, Ref: ref}
+			opts.OldOid = review.Commit
+		} else {
+			opts.Topic = session + "/" + commit[:7]
+		}
+
+		output, err := v.gitPush(&opts)
+		if err != nil {
+			return err
+		}
+		results := v.Remote.ParseReviewResults(output)
+		if len(results) > 0 {
+			review.ReviewResult = results[0]
+		}
+		if review.ID == "" {
+			if !config.IsDryRun() {
+				log.Warnf("%scannot find code review of commit %s",
+					p.Prompt(),
+					commit[:7])
+			}
+			continue
+		}
+		v.Reviews = append(v.Reviews, review.ReviewResult)
+		err = p.SetCommitReview(commit, review.ReviewResult)
+		if err != nil {
+			log.Warnf("%sfail to save code review of commit %s: %s",
+				p.Prompt(),
+				commit[:7],
+				err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReviewNote(t *testing.T) {
+	assert := assert.New(t)
+
+	notes := parseReviewNote(`Review-Id: 123
+Review-URL: https://example.com/main/merge_request/123
+Commit: cd6ea611bff6ba3a31ee17df95372af6e6441496
+
+Review-Id: 124
+Commit:
+`)
+	assert.Equal(map[string]string{
+		"review-id":  "124",
+		"review-url": "https://example.com/main/merge_request/123",
+		"commit":     "cd6ea611bff6ba3a31ee17df95372af6e6441496",
+	}, notes)
+
+	assert.Equal(map[string]string{}, parseReviewNote("no trailers\n"))
+}
@@ -1,11 +1,15 @@
 package project
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/alibaba/git-repo-go/cap"
 	"github.com/alibaba/git-repo-go/common"
 	"github.com/alibaba/git-repo-go/config"
 	"github.com/alibaba/git-repo-go/helper"
@@ -29,6 +33,7 @@ type ReviewableBranch struct {
 	Error       error
 	CodeReview  config.CodeReview // Push to update specific code review, only available for single repository mode.
 	Remote      *Remote
+	Reviews     []helper.ReviewResult // Code reviews created or updated by upload.
 
 	isPublished int
 }
@@ -49,8 +54,9 @@ func (v *ReviewableBranch) IsPublished() bool {
 	return false
 }
 
-// AppendReviewers adds reviewers to people.
-func (v ReviewableBranch) AppendReviewers(people [][]string) {
+// AppendReviewers adds reviewers from git config to people, expands
+// reviewer aliases, and removes duplicate reviewers and current user.
+func (v ReviewableBranch) AppendReviewers(people [][]string) error {
 	var (
 		review string
 	)
@@ -59,27 +65,49 @@ func (v ReviewableBranch) AppendReviewers(people [][]string) {
 	if v.Remote != nil {
 		review = v.Remote.Review
 	}
-	if review == "" {
-		return
-	}
 
-	key := fmt.Sprintf("review.%s.autoreviewer", review)
-	reviewers := cfg.Get(key)
-	if reviewers != "" {
-		for _, reviewer := range strings.Split(reviewers, ",") {
-			reviewer = strings.TrimSpace(reviewer)
-			people[0] = append(people[0], reviewer)
+	if review != "" {
+		key := fmt.Sprintf("review.%s.autoreviewer", review)
+		reviewers := cfg.Get(key)
+		if reviewers != "" {
+			for _, reviewer := range strings.Split(reviewers, ",") {
+				reviewer = strings.TrimSpace(reviewer)
+				people[0] = append(people[0], reviewer)
+			}
+		}
+
+		key = fmt.Sprintf("review.%s.autocopy", review)
+		reviewers = cfg.Get(key)
+		if reviewers != "" {
+			for _, reviewer := range strings.Split(reviewers, ",") {
+				reviewer = strings.TrimSpace(reviewer)
+				people[1] = append(people[1], reviewer)
+			}
 		}
 	}
 
-	key = fmt.Sprintf("review.%s.autocopy", review)
-	reviewers = cfg.Get(key)
-	if reviewers != "" {
-		for _, reviewer := range strings.Split(reviewers, ",") {
-			reviewer = strings.TrimSpace(reviewer)
-			people[1] = append(people[1], reviewer)
+	aliases := v.Project.ReviewerAliases()
+	email, login := v.Project.userIdentity()
+	found := make(map[string]bool)
+	for i := range people {
+		expanded, err := expandReviewers(people[i], aliases)
+		if err != nil {
+			return err
+		}
+		people[i] = people[i][:0]
+		for _, reviewer := range expanded {
+			key := strings.ToLower(reviewer)
+			if found[key] {
+				continue
+			}
+			found[key] = true
+			if email != "" && (key == strings.ToLower(email) || key == strings.ToLower(login)) {
+				continue
+			}
+			people[i] = append(people[i], reviewer)
 		}
 	}
+	return nil
 }
 
 // Published returns published reference.
@@ -123,10 +151,8 @@ func (v ReviewableBranch) Commits() []string {
 	return commits
 }
 
-// UploadForReview sends review for branch.
-func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
-	var err error
-
+// prepareUpload checks and fills remote and destination of upload options.
+func (v ReviewableBranch) prepareUpload(o *config.UploadOptions) error {
 	p := v.Project
 	if p == nil {
 		return fmt.Errorf("no project for reviewable branch")
@@ -142,6 +168,9 @@ func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
 	}
 	o.RemoteName = remoteName
 	o.RemoteURL = remoteURL
+	if o.UserEmail == "" {
+		o.UserEmail = p.UserEmail()
+	}
 
 	if v.CodeReview.Empty() && o.DestBranch == "" {
 		o.DestBranch = v.DestBranch
@@ -149,12 +178,107 @@ func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
 			return fmt.Errorf("no destination for review")
 		}
 	}
+	return nil
+}
+
+// ValidateUpload checks whether branch is ready for upload without pushing.
+func (v ReviewableBranch) ValidateUpload(o config.UploadOptions) error {
+	if v.Remote == nil || !v.Remote.ProtoHelperReady() {
+		return fmt.Errorf("remote is not reviewable")
+	}
+	err := v.prepareUpload(&o)
+	if err != nil {
+		return err
+	}
+	if len(v.Commits()) == 0 {
+		return fmt.Errorf("no commits for review")
+	}
+	_, err = v.Remote.GetGitPushCommand(&o)
+	return err
+}
 
-	pushCmd, err := v.Remote.GetGitPushCommand(o)
+// UploadForReview sends review for branch, and saves code reviews found
+// in the output of git push.
+func (v *ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
+	var err error
+
+	p := v.Project
+	err = v.prepareUpload(o)
 	if err != nil {
 		return err
 	}
 
+	v.Remote.GetCapabilities().CheckUploadOptions(o, v.Remote.GetType())
+	if o.Stacked {
+		err = v.uploadStacked(o)
+	} else {
+		var output []byte
+		output, err = v.gitPush(o)
+		if err == nil {
+			v.Reviews = v.Remote.ParseReviewResults(output)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	branchName := v.Branch.Name
+	if strings.HasPrefix(branchName, config.RefsHeads) {
+		branchName = strings.TrimPrefix(branchName, config.RefsHeads)
+	}
+
+	if len(v.Reviews) > 0 {
+		// Save the last one, which has all commits of stacked reviews.
+		err = p.SetBranchReview(branchName, v.Reviews[len(v.Reviews)-1])
+		if err != nil {
+			log.Warnf("%sfail to save code review for branch '%s': %s",
+				p.Prompt(),
+				branchName,
+				err)
+		}
+	}
+
+	var (
+		publishedRef string
+		msg          string
+	)
+	if v.CodeReview.Empty() {
+		publishedRef = config.RefsPub + branchName
+		msg = fmt.Sprintf("review from %s to %s on %s",
+			branchName,
+			o.DestBranch,
+			v.Remote.Review)
+	} else {
+		publishedRef = v.CodeReview.Ref
+		msg = fmt.Sprintf("update code review #%s of %s",
+			v.CodeReview.ID,
+			v.Remote.Review)
+	}
+	log.Debugf("%sUpdate reference '%s': %s",
+		v.Project.Prompt(),
+		publishedRef,
+		msg)
+	err = p.UpdateRef(publishedRef,
+		config.RefsHeads+branchName,
+		msg)
+
+	if err != nil {
+		return fmt.Errorf("fail to create or update reference '%s': %s",
+			publishedRef,
+			err)
+	}
+	return nil
+}
+
+// gitPush runs git push command returned by proto helper, and returns
+// messages from remote server.
+func (v ReviewableBranch) gitPush(o *config.UploadOptions) ([]byte, error) {
+	gitURL := config.ParseGitURL(o.RemoteURL)
+	pushCmd, err := v.Remote.GetGitPushCommand(o)
+	if err != nil {
+		return nil, err
+	}
+
 	cmdArgs := []string{pushCmd.Cmd}
 	if len(pushCmd.GitConfig) > 0 {
 		for _, c := range pushCmd.GitConfig {
@@ -174,6 +298,7 @@ func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
 		}
 	}
 
+	output := bytes.Buffer{}
 	if config.IsDryRun() || o.MockGitPush {
 		log.Notef("%swill execute command: %s",
 			v.Project.Prompt(),
@@ -181,15 +306,29 @@ func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
 		for _, env := range envs {
 			log.Notef("%swith extra environment: %s", v.Project.Prompt(), env)
 		}
+		if o.MockPushOutput != "" && !config.IsDryRun() {
+			data, err := ioutil.ReadFile(o.MockPushOutput)
+			if err != nil {
+				return nil, err
+			}
+			output.Write(data)
+		}
 	} else {
 		log.Debugf("%sreview by command: %s",
 			v.Project.Prompt(),
 			strings.Join(cmdArgs, " "))
+		// Stderr of git push is captured, and git will not show progress
+		// unless "--progress" is given.
+		if pushCmd.Cmd == config.GIT && cap.StderrIsatty() {
+			cmdArgs = withPushProgress(cmdArgs)
+		}
 		cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-		cmd.Dir = p.WorkDir
+		cmd.Dir = v.Project.WorkDir
 		cmd.Stdin = os.Stdin
+		// Messages from remote server (with "remote:" prefix) are send to
+		// stderr, save them to find code reviews.
 		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		cmd.Stderr = io.MultiWriter(os.Stderr, &output)
 		if len(envs) > 0 {
 			cmd.Env = []string{}
 			cmd.Env = append(cmd.Env, os.Environ()...)
@@ -200,45 +339,22 @@ func (v ReviewableBranch) UploadForReview(o *config.UploadOptions) error {
 		}
 		err = cmd.Run()
 		if err != nil {
-			return fmt.Errorf("upload failed: %s", err)
+			return nil, fmt.Errorf("upload failed: %s", err)
 		}
 	}
+	return output.Bytes(), nil
+}
 
-	branchName := v.Branch.Name
-	if strings.HasPrefix(branchName, config.RefsHeads) {
-		branchName = strings.TrimPrefix(branchName, config.RefsHeads)
-	}
-
-	var (
-		publishedRef string
-		msg          string
-	)
-	if v.CodeReview.Empty() {
-		publishedRef = config.RefsPub + branchName
-		msg = fmt.Sprintf("review from %s to %s on %s",
-			branchName,
-			o.DestBranch,
-			v.Remote.Review)
-	} else {
-		publishedRef = v.CodeReview.Ref
-		msg = fmt.Sprintf("update code review #%s of %s",
-			v.CodeReview.ID,
-			v.Remote.Review)
-	}
-	log.Debugf("%sUpdate reference '%s': %s",
-		v.Project.Prompt(),
-		publishedRef,
-		msg)
-	err = p.UpdateRef(publishedRef,
-		config.RefsHeads+branchName,
-		msg)
-
-	if err != nil {
-		return fmt.Errorf("fail to create or update reference '%s': %s",
-			publishedRef,
-			err)
+// withPushProgress adds "--progress" option after "push" of git command.
+func withPushProgress(cmdArgs []string) []string {
+	for i, arg := range cmdArgs {
+		if arg == "push" {
+			args := append([]string{}, cmdArgs[:i+1]...)
+			args = append(args, "--progress")
+			return append(args, cmdArgs[i+1:]...)
+		}
 	}
-	return nil
+	return cmdArgs
 }
 
 // GetUploadableBranch returns branch which has commits ready for upload.
@@ -0,0 +1,18 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPushProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"git", "push", "--progress", "origin", "HEAD"},
+		withPushProgress([]string{"git", "push", "origin", "HEAD"}))
+	assert.Equal([]string{"git", "-c", "http.extraHeader=X: 1", "push", "--progress", "origin"},
+		withPushProgress([]string{"git", "-c", "http.extraHeader=X: 1", "push", "origin"}))
+	assert.Equal([]string{"helper", "upload"},
+		withPushProgress([]string{"helper", "upload"}))
+}
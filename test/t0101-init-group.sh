@@ -122,4 +122,30 @@ test_expect_success "platform = auto, groups = default" '
 	)
 '
 
+test_expect_success "platform = linux, groups = (app|drivers)&!notdefault" '
+	(
+		cd work &&
+		git-repo init -p linux -g "(app|drivers)&!notdefault" -u $manifest_url &&
+		echo "(app|drivers)&!notdefault,platform-linux" >expect &&
+		(
+			cd .repo/manifests &&
+			git config manifest.groups
+		) >actual &&
+		test_cmp expect actual
+	)
+'
+
+test_expect_success "bad groups expression" '
+	(
+		cd work &&
+		test_must_fail git-repo init -g "app&" -u $manifest_url &&
+		echo "(app|drivers)&!notdefault,platform-linux" >expect &&
+		(
+			cd .repo/manifests &&
+			git config manifest.groups
+		) >actual &&
+		test_cmp expect actual
+	)
+'
+
 test_done
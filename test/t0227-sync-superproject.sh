@@ -0,0 +1,149 @@
+#!/bin/sh
+
+test_description="sync using superproject and show contactinfo on errors"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${HOME}/repositories/hello/manifests"
+
+test_expect_success "setup" '
+	(
+		# create .repo file as a barrier, not find .repo deeper
+		touch .repo &&
+		mkdir repositories &&
+		cd repositories &&
+		ln -s "${REPO_TEST_REPOSITORIES}/drivers" . &&
+		ln -s "${REPO_TEST_REPOSITORIES}/others"  . &&
+		mkdir hello &&
+		cd hello &&
+		ln -s "${REPO_TEST_REPOSITORIES}/hello/main.git" . &&
+		ln -s "${REPO_TEST_REPOSITORIES}/hello/project1.git" . &&
+		ln -s "${REPO_TEST_REPOSITORIES}/hello/project2.git" . &&
+		ln -s "${REPO_TEST_REPOSITORIES}/hello/project1" . &&
+		git clone --mirror \
+			"${REPO_TEST_REPOSITORIES}/hello/manifests.git" \
+			manifests.git
+	)
+'
+
+test_expect_success "setup superproject" '
+	(
+		cd repositories/hello &&
+		git init --bare superproject.git &&
+		git init superproject &&
+		cd superproject &&
+		git update-index --add --cacheinfo \
+			160000,$(git -C ../main.git rev-parse Maint~1),main &&
+		git update-index --add --cacheinfo \
+			160000,$(git -C ../project2.git rev-parse Maint~2),projects/app2 &&
+		test_tick &&
+		git commit -m "superproject" &&
+		git push ../superproject.git HEAD:refs/heads/Maint
+	)
+'
+
+test_expect_success "setup manifests: with superproject and contactinfo" '
+	(
+		git clone -b Maint "${manifest_url}.git" manifests &&
+		cd manifests &&
+		cat >default.xml <<-EOF &&
+		<?xml version="1.0" encoding="UTF-8"?>
+		<manifest>
+		  <remote  name="aone"
+			   alias="origin"
+			   fetch="."
+			   review="https://example.com" />
+		  <default remote="aone"
+			   revision="Maint"
+			   sync-j="4" />
+		  <superproject name="superproject" />
+		  <contactinfo bugurl="https://example.com/bugs" />
+		  <project name="main" path="main" groups="app" />
+		  <project name="project1" path="projects/app1" groups="app" />
+		  <project name="project2" path="projects/app2" groups="app" />
+		</manifest>
+		EOF
+		git add -u &&
+		test_tick &&
+		git commit -m "default.xml: add superproject and contactinfo" &&
+		git push origin HEAD
+	)
+'
+
+test_expect_success "manifest keeps superproject and contactinfo" '
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u "$manifest_url" -b Maint &&
+		git-repo manifest
+	) >actual &&
+	cat >expect <<-EOF &&
+	<manifest>
+	  <remote name="aone" alias="origin" fetch="." review="https://example.com"></remote>
+	  <default remote="aone" revision="Maint" sync-j="4"></default>
+	  <project name="main" path="main" groups="app"></project>
+	  <project name="project1" path="projects/app1" groups="app"></project>
+	  <project name="project2" path="projects/app2" groups="app"></project>
+	  <superproject name="superproject"></superproject>
+	  <contactinfo bugurl="https://example.com/bugs"></contactinfo>
+	</manifest>
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "sync --use-superproject" '
+	(
+		cd work &&
+		git-repo sync --use-superproject \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}"
+	) &&
+	cat >expect <<-EOF &&
+	main: $(git -C repositories/hello/main.git rev-parse Maint~1)
+	projects/app1: $(git -C repositories/hello/project1.git rev-parse Maint)
+	projects/app2: $(git -C repositories/hello/project2.git rev-parse Maint~2)
+	EOF
+	(
+		cd work &&
+		for p in main projects/app1 projects/app2
+		do
+			echo "$p: $(git -C $p rev-parse HEAD)" || return 1
+		done
+	) >actual &&
+	test_cmp expect actual
+'
+
+test_expect_success "start branch tracks revision in manifest" '
+	(
+		cd work &&
+		git-repo start --all jx/topic &&
+		git -C main config branch.jx/topic.merge
+	) >actual &&
+	cat >expect <<-EOF &&
+	refs/heads/Maint
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "show contactinfo on error" '
+	(
+		cd work &&
+		mkdir -p .repo/local_manifests &&
+		cat >.repo/local_manifests/bad.xml <<-EOF &&
+		<manifest>
+		  <remove-project name="not-exist" />
+		</manifest>
+		EOF
+		test_must_fail git-repo manifest >actual 2>&1 &&
+		rm .repo/local_manifests/bad.xml &&
+		grep "^Please report" actual >../actual
+	) &&
+	cat >expect <<-EOF &&
+	Please report problems of the manifest to: https://example.com/bugs
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,244 @@
+#!/bin/sh
+
+test_description="upload report with code reviews found in git push output"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add topic1.txt &&
+		test_tick &&
+		git commit -m "topic1: new file"
+	) &&
+	cat >push-output <<-EOF
+	remote: +------------------------------------------------------------------------+
+	remote: | Merge request #123 was created or updated.                             |
+	remote: | View merge request for details:                                        |
+	remote: |   https://example.com/main/merge_request/123                           |
+	remote: +------------------------------------------------------------------------+
+	To ssh://ssh.example.com/main.git
+	 * [new branch]      my/topic1 -> refs/for/Maint/my/topic1
+	EOF
+'
+
+test_expect_success "upload and show code review in summary" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-push-output ../push-output \
+			--report-json ../report.json \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^------/,\$p" out >actual &&
+	cat >expect <<-EOF &&
+	----------------------------------------------------------------------
+	[OK    ] main/           my/topic1       https://example.com/main/merge_request/123
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "code review saved in git config of branch" '
+	(
+		cd work/main &&
+		git config branch.my/topic1.reviewid &&
+		git config branch.my/topic1.reviewurl
+	) >actual &&
+	cat >expect <<-EOF &&
+	123
+	https://example.com/main/merge_request/123
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "upload report in JSON" '
+	cat >expect <<-EOF &&
+	[
+	  {
+	    "project": "main",
+	    "path": "main",
+	    "branch": "my/topic1",
+	    "uploaded": true,
+	    "reviews": [
+	      {
+	        "id": "123",
+	        "url": "https://example.com/main/merge_request/123"
+	      }
+	    ]
+	  }
+	]
+	EOF
+	test_cmp expect report.json
+'
+
+test_expect_success "upload report to stdout" '
+	(
+		cd work/main &&
+		echo hack >>topic1.txt &&
+		git add topic1.txt &&
+		test_tick &&
+		git commit -m "topic1: update"
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--report-json - \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			2>/dev/null
+	) >out &&
+	sed -n -e "/^\[\$/,\$p" out >actual &&
+	cat >expect <<-EOF &&
+	[
+	  {
+	    "project": "main",
+	    "path": "main",
+	    "branch": "my/topic1",
+	    "uploaded": true
+	  }
+	]
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "warn for options not supported by agit" '
+	(
+		cd work/main &&
+		echo hack >>topic1.txt &&
+		git add topic1.txt &&
+		test_tick &&
+		git commit -m "topic1: update again"
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--topic feature-x \
+			--label Code-Review+1 \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	grep "^WARNING: label is not supported by agit, ignored" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for/Maint/feature-x$" out
+'
+
+test_expect_success "upload again to update saved code review" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--mock-review-query-response "{\"id\":123, \"state\":\"open\"}" \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	grep "^NOTE: main> will update code review #123 uploaded from branch .my/topic1. before$" out &&
+	grep "^Upload code review #123 of project (main):$" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for-review/123$" out
+'
+
+test_expect_success "create new code review with --new-review" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--new-review \
+			--mock-review-query-response "{\"id\":123, \"state\":\"open\"}" \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	test_must_fail grep "will update code review" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for/Maint/my/topic1$" out
+'
+
+test_expect_success "not check saved code review in dryrun mode" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	test_must_fail grep "will update code review" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for/Maint/my/topic1$" out
+'
+
+test_expect_success "create new code review if saved one is merged" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--mock-review-query-response "{\"id\":123, \"state\":\"merged\"}" \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	test_must_fail grep "will update code review" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for/Maint/my/topic1$" out
+'
+
+test_expect_success "backport does not update saved code review" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--dest Maint \
+			--dest-branch master \
+			--mock-review-query-response "{\"id\":123, \"state\":\"open\"}" \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	test_must_fail grep "will update code review\|refs/for-review/" out &&
+	grep "^NOTE: main> will execute command: git push .* refs/heads/my/topic1:refs/for/Maint/my/topic1$" out &&
+	grep "^NOTE: main> will execute command: git push .* [0-9a-f]\{40\}:refs/for/master/my/topic1$" out
+'
+
+test_done
@@ -0,0 +1,181 @@
+#!/bin/sh
+
+test_description="upload branches of a topic atomically"
+
+. lib/test-lib.sh
+
+PATH="$HOME/bin":$PATH
+LOCAL_HELPER_REJECT=no-such-project
+export PATH LOCAL_HELPER_REJECT
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	mkdir pushes &&
+	git init --bare pushes/main.git &&
+	mkdir bin &&
+	write_script bin/git-repo-helper-proto-local <<-\EOF &&
+	test "$1" = "--upload" || exit 1
+	input=$(cat)
+	url=$(echo "$input" | sed -e "s/.*\"RemoteURL\":\"\([^\"]*\)\".*/\1/")
+	branch=$(echo "$input" | sed -e "s/.*\"LocalBranch\":\"\([^\"]*\)\".*/\1/")
+	topic=$(echo "$input" | sed -e "s/.*\"Topic\":\"\([^\"]*\)\".*/\1/")
+	case "$url" in
+	*$LOCAL_HELPER_REJECT*)
+		echo >&2 "reject $url"
+		exit 1
+		;;
+	esac
+	cat <<-EOF2
+	{"cmd": "git", "args": ["push", "$url", "refs/heads/$branch:refs/heads/$topic"]}
+	EOF2
+	EOF
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" &&
+		git repo start --all my/topic1 &&
+		test_tick &&
+		(
+			cd main &&
+			echo hack >topic1.txt &&
+			git add topic1.txt &&
+			git commit -m "topic1: new file"
+		) &&
+		test_tick &&
+		(
+			cd projects/app1 &&
+			echo hack >topic1.txt &&
+			git add topic1.txt &&
+			git commit -m "topic1: new file"
+		)
+	)
+'
+
+test_expect_success "--atomic must be used with --topic" '
+	(
+		cd work &&
+		test_must_fail git-repo upload --atomic \
+			--assume-yes \
+			--no-edit \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" \
+			>actual 2>&1 &&
+		cat >expect <<-EOF &&
+		Error: --atomic and --resume must be used with --topic
+		EOF
+		test_cmp expect actual
+	)
+'
+
+test_expect_success "nothing uploaded if validation failed" '
+	(
+		cd work &&
+		test_must_fail env LOCAL_HELPER_REJECT=project1 git-repo upload \
+			--topic feature-x \
+			--atomic \
+			--assume-yes \
+			--no-edit \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^ERROR/,\$p" out | sed -e "s#file://.*/pushes/#file://<pushes>/#" >actual &&
+	cat >expect <<-EOF &&
+	ERROR: validation failed, nothing uploaded
+	
+	----------------------------------------------------------------------
+	[FAILED] main/           my/topic1      
+	       (not uploaded, for other branches are invalid)
+	[FAILED] projects/app1/  my/topic1      
+	       (fail to run git-repo-helper-proto-local: reject file://<pushes>/project1.git)
+	
+	Run "git repo upload --topic feature-x --resume" to retry failed branches.
+	
+	EOF
+	test_cmp expect actual &&
+	git -C pushes/main.git for-each-ref >actual &&
+	test_must_be_empty actual
+'
+
+test_expect_success "atomic upload stops on failure" '
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--topic feature-x \
+			--atomic \
+			--assume-yes \
+			--no-edit \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^------/,\$p" out >actual &&
+	cat >expect <<-EOF &&
+	----------------------------------------------------------------------
+	[OK    ] main/           my/topic1
+	[FAILED] projects/app1/  my/topic1       (upload failed: exit status 128)
+	
+	Run "git repo upload --topic feature-x --resume" to retry failed branches.
+	
+	EOF
+	test_cmp expect actual &&
+	git -C pushes/main.git show-ref --verify refs/heads/feature-x &&
+	test -f work/.repo/UPLOAD_TOPICS.d/feature-x
+'
+
+test_expect_success "resume failed uploads" '
+	git init --bare pushes/project1.git &&
+	(
+		cd work &&
+		git-repo upload \
+			--topic feature-x \
+			--resume \
+			--assume-yes \
+			--no-edit \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^------/,\$p" out >actual &&
+	cat >expect <<-EOF &&
+	----------------------------------------------------------------------
+	[OK    ] projects/app1/  my/topic1
+	EOF
+	test_cmp expect actual &&
+	git -C pushes/project1.git show-ref --verify refs/heads/feature-x &&
+	test ! -f work/.repo/UPLOAD_TOPICS.d/feature-x
+'
+
+test_expect_success "nothing to resume" '
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--topic feature-x \
+			--resume \
+			--assume-yes \
+			--no-edit \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"pushurl\":\"file://$HOME/pushes\", \"type\":\"local\"}" \
+			>../out 2>&1
+	) &&
+	grep "^Error" out >actual &&
+	cat >expect <<-EOF &&
+	Error: no failed upload of topic '"'"'feature-x'"'"' to resume
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,158 @@
+#!/bin/sh
+
+test_description="upload each commit as its own review (stacked reviews)"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add topic1.txt &&
+		test_tick &&
+		git commit -m "topic1: new file" &&
+		echo hack >topic2.txt &&
+		git add topic2.txt &&
+		test_tick &&
+		git commit -m "topic2: new file"
+	) &&
+	cat >push-output <<-EOF
+	remote: +------------------------------------------------------------------------+
+	remote: | Merge request #123 was created or updated.                             |
+	remote: | View merge request for details:                                        |
+	remote: |   https://example.com/main/merge_request/123                           |
+	remote: +------------------------------------------------------------------------+
+	EOF
+'
+
+test_expect_success "upload each commit as a review" '
+	(
+		cd work &&
+		git-repo upload \
+			--stacked \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push ssh://git@ssh.example.com/main.git 76a1c9f6c318488ee62be7aa7ada7046fa5d8b67:refs/for/Maint/my/topic1/76a1c9f
+	NOTE: main> will execute command: git push ssh://git@ssh.example.com/main.git cd6ea611bff6ba3a31ee17df95372af6e6441496:refs/for/Maint/my/topic1/cd6ea61
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "save code reviews of commits in notes" '
+	(
+		cd work &&
+		git-repo upload \
+			--stacked \
+			--re-run \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-push-output ../push-output \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^------/,\$p" out >actual &&
+	cat >expect <<-EOF &&
+	----------------------------------------------------------------------
+	[OK    ] main/           my/topic1       https://example.com/main/merge_request/123
+	[OK    ] main/           my/topic1       https://example.com/main/merge_request/123
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main &&
+		git notes --ref refs/notes/review-ids show HEAD &&
+		git config notes.rewriteRef
+	) >actual &&
+	cat >expect <<-EOF &&
+	Review-Id: 123
+	Review-URL: https://example.com/main/merge_request/123
+	Commit: cd6ea611bff6ba3a31ee17df95372af6e6441496
+	refs/notes/review-ids
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "update changed review with old-oid" '
+	(
+		cd work/main &&
+		echo hack >>topic2.txt &&
+		git add topic2.txt &&
+		test_tick &&
+		git commit --amend --no-edit
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--stacked \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command\|is not changed" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> commit 76a1c9f is not changed, skip updating review #123
+	NOTE: main> will execute command: git push -o old-oid=cd6ea611bff6ba3a31ee17df95372af6e6441496 ssh://git@ssh.example.com/main.git 1db3fba5c28ddcc250ba7e99d83fccf59f0e0559:refs/for-review/123
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "review of commit set by trailer" '
+	(
+		cd work/main &&
+		echo hack >topic3.txt &&
+		git add topic3.txt &&
+		test_tick &&
+		git commit -m "topic3: new file" -m "Review-Id: 456"
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--stacked \
+			--re-run \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\", \"version\":2}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command\|is not changed" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> commit 76a1c9f is not changed, skip updating review #123
+	NOTE: main> commit 1db3fba is not changed, skip updating review #123
+	NOTE: main> will execute command: git push ssh://git@ssh.example.com/main.git 36b06822b0e13ca70e847535a65adf3dd776cb55:refs/for-review/456
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,189 @@
+#!/bin/sh
+
+test_description="upload with reviewers suggested by OWNERS and CODEOWNERS"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		mkdir -p docs &&
+		cat >CODEOWNERS <<-EOF &&
+		# default owners
+		*               committer@example.com owner@example.com
+		*.txt           @alice
+		/docs/          bob@example.com
+		EOF
+		echo hack >topic1.txt &&
+		echo hack >docs/README &&
+		git add -A &&
+		test_tick &&
+		git commit -m "topic1: new files"
+	) &&
+	(
+		cd work/projects/app1 &&
+		mkdir -p src/module &&
+		cat >OWNERS <<-EOF &&
+		root@example.com
+		EOF
+		cat >src/module/OWNERS <<-EOF &&
+		# module owners
+		module@example.com
+		per-file *.c=c-owner@example.com
+		EOF
+		git add -A &&
+		test_tick &&
+		git commit -m "add owners" &&
+		echo hack >src/module/main.c &&
+		git add -A &&
+		test_tick &&
+		git commit -m "module: new file"
+	)
+'
+
+test_expect_success "show reviewers from owners before upload" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep -v "^ *[0-9a-f]\{40\}$" out >actual &&
+	cat >expect <<-EOF &&
+	[1/2] project main: my/topic1
+	Upload project main/ to remote branch Maint:
+	  branch my/topic1 ( 1 commit(s)):
+	  reviewers from owners: owner@example.com, bob@example.com, alice
+	to https://example.com (y/N)? Yes
+	[2/2] project projects/app1: my/topic1
+	Upload project projects/app1/ to remote branch Maint:
+	  branch my/topic1 ( 2 commit(s)):
+	  reviewers from owners: c-owner@example.com, module@example.com, root@example.com
+	to https://example.com (y/N)? Yes
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint%r=owner@example.com,r=bob@example.com,r=alice
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint%r=c-owner@example.com,r=module@example.com,r=root@example.com
+	
+	----------------------------------------------------------------------
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "suggested reviewers are not duplicated" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--re-run \
+			--reviewers alice,user@example.com \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint%r=alice,r=user@example.com,r=owner@example.com,r=bob@example.com
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint%r=alice,r=user@example.com,r=c-owner@example.com,r=module@example.com,r=root@example.com
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "upload with --no-auto-reviewers" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--no-auto-reviewers \
+			--mock-git-push \
+			--re-run \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "reviewers from owners\|will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "reviewers from owners in editor script" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--mock-git-push \
+			--re-run \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep -e "^# project" -e "^#   reviewers:" out >actual &&
+	cat >expect <<-EOF &&
+	# project main/:
+	#   reviewers: owner@example.com, bob@example.com, alice
+	# project projects/app1/:
+	#   reviewers: c-owner@example.com, module@example.com, root@example.com
+	EOF
+	test_cmp expect actual &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint%r=owner@example.com,r=bob@example.com,r=alice
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint%r=c-owner@example.com,r=module@example.com,r=root@example.com
+	EOF
+	test_cmp expect actual &&
+	test -f work/.repo/UPLOAD_OPTIONS.d/Maint &&
+	test_must_fail grep "@example.com" work/.repo/UPLOAD_OPTIONS.d/Maint
+'
+
+test_expect_success "edit reviewers from owners in editor script" '
+	sed -n -e "/^# Step 2/,\$p" out |
+	sed -e "s/^#  branch/   branch/" \
+	    -e "s/^\(#   reviewers:\) owner@example.com, \(.*\)/\1 \2/" \
+	    -e "/^#   reviewers: c-owner/d" >edit-script &&
+	(
+		cd work &&
+		git-repo upload \
+			--mock-edit-script ../edit-script \
+			--mock-git-push \
+			--re-run \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint%r=bob@example.com,r=alice
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint
+	EOF
+	test_cmp expect actual
+'
+
+test_done
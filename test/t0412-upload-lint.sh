@@ -0,0 +1,149 @@
+#!/bin/sh
+
+test_description="check commits before upload"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		git config upload.lint.maxFileSize 1k &&
+		printf "\000binary" >data.bin &&
+		test_seq 1 1000 >large.txt &&
+		git add -A &&
+		test_tick &&
+		git commit --no-verify \
+			-m "topic1: a very long subject which has more than seventy two characters in one line" &&
+		echo hack >topic2.txt &&
+		git add -A &&
+		test_tick &&
+		git commit -m "topic2: new file"
+	)
+'
+
+test_expect_success "upload blocked by errors of pre-upload checks" '
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	sed -e "s/[0-9a-f]\{40\}/<hash>/g" -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" -e "s/ *$//" out >actual &&
+	cat >expect <<-EOF &&
+	Upload project main/ to remote branch Maint:
+	  branch my/topic1 ( 2 commit(s)):
+	         <hash>
+	         <hash>
+	  ERROR: <abbrev>: missing Change-Id in commit message
+	  WARNING: <abbrev>: subject is too long (82 > 72)
+	  WARNING: <abbrev>: binary file '"'"'data.bin'"'"'
+	  ERROR: <abbrev>: file '"'"'large.txt'"'"' is too large (3893 > 1024 bytes)
+	to https://example.com (y/N)? Yes
+	
+	----------------------------------------------------------------------
+	[FAILED] main/           my/topic1
+	       (2 error(s) found by pre-upload checks, use --no-verify to bypass)
+	
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "results of pre-upload checks in editor script" '
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^# project main/,/^\$/p" out |
+		sed -e "s/[0-9a-f]\{40\}/<hash>/g" -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" >actual &&
+	cat >expect <<-EOF &&
+	# project main/:
+	   branch my/topic1 ( 2 commit(s)) to remote branch Maint:
+	#         <hash>
+	#         <hash>
+	#   ERROR: <abbrev>: missing Change-Id in commit message
+	#   WARNING: <abbrev>: subject is too long (82 > 72)
+	#   WARNING: <abbrev>: binary file '"'"'data.bin'"'"'
+	#   ERROR: <abbrev>: file '"'"'large.txt'"'"' is too large (3893 > 1024 bytes)
+	
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "upload with --no-verify" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--no-verify \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "change levels of rules in git config" '
+	(
+		cd work/main &&
+		git config upload.lint.changeId off &&
+		git config upload.lint.largeFile warning &&
+		git config upload.lint.binaryFile off &&
+		git config upload.lint.maxSubjectLength 100 &&
+		git config upload.lint.signOff error
+	) &&
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--re-run \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "^  [A-Z]*:" out |
+		sed -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" >actual &&
+	cat >expect <<-EOF &&
+	  ERROR: <abbrev>: missing Signed-off-by in commit message
+	  WARNING: <abbrev>: file '"'"'large.txt'"'"' is too large (3893 > 1024 bytes)
+	  ERROR: <abbrev>: missing Signed-off-by in commit message
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,90 @@
+#!/bin/sh
+
+test_description="upload with reviewer aliases"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add -A &&
+		test_tick &&
+		git commit -m "topic1: new file" &&
+		git config review.alias.camera "alice@example.com, team-isp"
+	) &&
+	cat >work/.repo/manifests/review-aliases <<-EOF
+	[review "alias"]
+		team-isp = bob@example.com, committer@example.com
+		camera = nobody@example.com
+	EOF
+'
+
+test_expect_success "expand reviewer aliases" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--no-auto-reviewers \
+			--mock-git-push \
+			--reviewers Camera,alice@example.com \
+			--cc bob@example.com,carol@example.com \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint%r=alice@example.com,r=bob@example.com,cc=carol@example.com
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "upload fails for cycle in reviewer aliases" '
+	(
+		cd work/main &&
+		git config review.alias.team-a "team-b" &&
+		git config review.alias.team-b "alice@example.com, team-a"
+	) &&
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--re-run \
+			--no-auto-reviewers \
+			--mock-git-push \
+			--reviewers team-a \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^------/,\$p" out | sed -e "s/ *$//" >actual &&
+	cat >expect <<-EOF &&
+	----------------------------------------------------------------------
+	[FAILED] main/           my/topic1
+	       (cycle in reviewer aliases: team-a -> team-b -> team-a)
+	
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,109 @@
+#!/bin/sh
+
+test_description="upload with interactive picker"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add -A &&
+		test_tick &&
+		git commit -m "main: new file"
+	) &&
+	(
+		cd work/projects/app1 &&
+		echo hack >topic1.txt &&
+		git add -A &&
+		test_tick &&
+		git commit -m "app1: new file"
+	)
+'
+
+test_expect_success "quit picker without upload" '
+	(
+		cd work &&
+		printf "1\nq\n" >../input &&
+		test_must_fail git-repo upload \
+			--mock-picker-input ../input \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			>../out 2>&1
+	) &&
+	! grep "will execute command" out &&
+	tail -1 out >actual &&
+	cat >expect <<-EOF &&
+	What now> Error: upload aborted by user
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "select branches and edit options in picker" '
+	(
+		cd work &&
+		cat >../input <<-EOF &&
+		l 2
+		3
+		1-2
+		t 1 Title of main
+		r 2 user1, user2
+		d 2
+		u
+		EOF
+		git-repo upload \
+			--mock-picker-input ../input \
+			--mock-git-push \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^What now> /,\$p" out |
+	sed -e "s/^What now>   [0-9a-f]\{7\} /What now>   <abbrev> /" >actual &&
+	cat >expect <<-EOF &&
+	What now>   <abbrev> app1: new file
+	What now> error: index '"'"'3'"'"' out of range (1-2)
+	What now> Branches ready for upload:
+	  1: [*] main/ my/topic1 (1 commit(s)) to remote branch Maint
+	  2: [*] projects/app1/ my/topic1 (1 commit(s)) to remote branch Maint
+	What now> Branches ready for upload:
+	  1: [*] main/ my/topic1 (1 commit(s)) to remote branch Maint
+	          title: Title of main
+	  2: [*] projects/app1/ my/topic1 (1 commit(s)) to remote branch Maint
+	What now> Branches ready for upload:
+	  1: [*] main/ my/topic1 (1 commit(s)) to remote branch Maint
+	          title: Title of main
+	  2: [*] projects/app1/ my/topic1 (1 commit(s)) to remote branch Maint
+	          reviewers: user1, user2
+	What now> Branches ready for upload:
+	  1: [*] main/ my/topic1 (1 commit(s)) to remote branch Maint
+	          title: Title of main
+	  2: [*] projects/app1/ my/topic1 (1 commit(s)) to remote branch Maint
+	          reviewers: user1, user2; draft
+	What now> NOTE: main> will execute command: git push --receive-pack=agit-receive-pack -o title=Title of main ssh://git@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint/my/topic1
+	NOTE: projects/app1> will execute command: git push --receive-pack=agit-receive-pack -o reviewers=user1,user2 ssh://git@ssh.example.com/project1.git refs/heads/my/topic1:refs/drafts/Maint/my/topic1
+	
+	----------------------------------------------------------------------
+	EOF
+	test_cmp expect actual
+'
+
+test_done
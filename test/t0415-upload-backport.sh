@@ -0,0 +1,144 @@
+#!/bin/sh
+
+test_description="upload and backport to multiple destination branches"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add -A &&
+		test_tick &&
+		git commit -m "main: new file"
+	)
+'
+
+test_expect_success "upload and backport to master" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--dest Maint \
+			--dest-branch master \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	grep -v "^ *[0-9a-f]\{40\}$" out |
+	sed -e "s/[0-9a-f]\{40\}:refs/<commit>:refs/" >actual &&
+	cat >expect <<-EOF &&
+	Upload project main/ to remote branch Maint:
+	  branch my/topic1 ( 1 commit(s)):
+	  backport to: master
+	to https://example.com (y/N)? Yes
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint/my/topic1
+	NOTE: main> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/main.git <commit>:refs/for/master/my/topic1
+	
+	----------------------------------------------------------------------
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "backport keeps Change-Id, and worktree is not touched" '
+	(
+		cd work/main &&
+		commit=$(sed -n -e "s/.* \([0-9a-f]\{40\}\):refs\/for\/master.*/\1/p" ../../out) &&
+		git log -1 --format=%B $commit >../../backport-msg &&
+		git log -1 --format=%B HEAD >../../orig-msg &&
+		test "$(git rev-parse $commit^)" = "$(git rev-parse aone/master)" &&
+		test "$(git symbolic-ref HEAD)" = "refs/heads/my/topic1" &&
+		test "$(git worktree list | wc -l)" -eq 1 &&
+		git status --porcelain >../../status
+	) &&
+	test_must_be_empty status &&
+	grep "^Change-Id: I[0-9a-f]\{40\}$" orig-msg >expect &&
+	grep "^Change-Id: " backport-msg >actual &&
+	test_cmp expect actual &&
+	grep "^(cherry picked from commit [0-9a-f]\{40\})$" backport-msg
+'
+
+test_expect_success "conflicting backport is reported" '
+	(
+		cd work/projects/app1 &&
+		echo v1.0.1-dev >VERSION &&
+		git add -A &&
+		test_tick &&
+		git commit -m "app1: bump version"
+	) &&
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--dest Maint \
+			--dest master \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			projects/app1 \
+			>../out 2>&1
+	) &&
+	sed -n -e "/^NOTE: /,\$p" out |
+	sed -e "s/[0-9a-f]\{7\} onto [0-9a-f]\{40\}/<abbrev> onto <commit>/" \
+		-e "s/ *\$//" >actual &&
+	cat >expect <<-EOF &&
+	NOTE: projects/app1> will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com/project1.git refs/heads/my/topic1:refs/for/Maint/my/topic1
+	
+	----------------------------------------------------------------------
+	[FAILED] projects/app1/  my/topic1
+	       (backport to master: conflict when cherry-picking <abbrev> onto <commit>)
+	
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/projects/app1 &&
+		test "$(cat VERSION)" = "v1.0.1-dev" &&
+		test "$(git worktree list | wc -l)" -eq 1 &&
+		git status --porcelain >../../../status
+	) &&
+	test_must_be_empty status
+'
+
+test_expect_success "cannot backport with --atomic" '
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--atomic \
+			--topic my-topic \
+			--dest Maint \
+			--dest master \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" \
+			>../out 2>&1
+	) &&
+	cat >expect <<-EOF &&
+	Error: cannot backport to multiple branches with --atomic
+	EOF
+	test_cmp expect out
+'
+
+test_done
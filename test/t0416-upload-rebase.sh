@@ -0,0 +1,239 @@
+#!/bin/sh
+
+test_description="upload with --rebase onto the latest upstream"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+# Push a new commit to upstream branch Maint of a bare repository.
+push_upstream () {
+	repo=$1 &&
+	file=$2 &&
+	content=$3 &&
+	rm -rf tmp-clone &&
+	git clone -q -b Maint "$repo" tmp-clone &&
+	(
+		cd tmp-clone &&
+		echo "$content" >"$file" &&
+		git add "$file" &&
+		test_tick &&
+		git commit -q -m "upstream: change $file" &&
+		git push -q origin Maint
+	) &&
+	rm -rf tmp-clone
+}
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	git clone -q --bare "${REPO_TEST_REPOSITORIES}/hello/main.git" upstream-main.git &&
+	git clone -q --bare "${REPO_TEST_REPOSITORIES}/hello/project1.git" upstream-app1.git &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" &&
+		git-repo start --all my/topic1
+	) &&
+	git -C work/main remote set-url aone "$HOME/upstream-main.git" &&
+	git -C work/projects/app1 remote set-url aone "$HOME/upstream-app1.git" &&
+	(
+		cd work/main &&
+		echo hack >topic1.txt &&
+		git add topic1.txt &&
+		test_tick &&
+		git commit -m "main: new file"
+	) &&
+	(
+		cd work/projects/app1 &&
+		echo v1.0.1-dev >VERSION &&
+		git add VERSION &&
+		test_tick &&
+		git commit -m "app1: bump version"
+	) &&
+	push_upstream upstream-main.git upstream.txt upstream &&
+	push_upstream upstream-app1.git VERSION v1.0.2-dev
+'
+
+test_expect_success "rebase onto the latest upstream before upload" '
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--rebase \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			main \
+			>../out 2>&1
+	) &&
+	grep -v "^ *[0-9a-f]\{40\}$" out >actual &&
+	cat >expect <<-EOF &&
+	Upload project main/ to remote branch Maint:
+	  branch my/topic1 ( 1 commit(s)):
+	to https://example.com (y/N)? Yes
+	NOTE: main> rebasing '"'"'my/topic1'"'"' onto '"'"'refs/remotes/aone/Maint'"'"'
+	NOTE: main> will execute command: git push --receive-pack=agit-receive-pack ssh://git@ssh.example.com/main.git refs/heads/my/topic1:refs/for/Maint/my/topic1
+	
+	----------------------------------------------------------------------
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main &&
+		git log --format=%s -2 >../../actual &&
+		test "$(git rev-parse HEAD^)" = "$(git rev-parse aone/Maint)"
+	) &&
+	cat >expect <<-EOF &&
+	main: new file
+	upstream: change upstream.txt
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "rebase branch not checked out in a temporary worktree" '
+	(
+		cd work/main &&
+		git checkout -q -b my/topic2 HEAD^^ &&
+		echo hack >topic2.txt &&
+		git add topic2.txt &&
+		test_tick &&
+		git commit -q -m "main: topic2" &&
+		git branch --set-upstream-to aone/Maint &&
+		git checkout -q my/topic1
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--rebase \
+			--br my/topic2 \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			main \
+			>../out 2>&1
+	) &&
+	grep "will execute command" out >actual &&
+	cat >expect <<-EOF &&
+	NOTE: main> will execute command: git push --receive-pack=agit-receive-pack ssh://git@ssh.example.com/main.git refs/heads/my/topic2:refs/for/Maint/my/topic2
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main &&
+		test "$(git symbolic-ref HEAD)" = "refs/heads/my/topic1" &&
+		test "$(git rev-parse my/topic2^)" = "$(git rev-parse aone/Maint)" &&
+		test "$(git worktree list | wc -l)" -eq 1
+	)
+'
+
+test_expect_success "rebase aborted on conflict, and upload is skipped" '
+	(
+		cd work/projects/app1 &&
+		git rev-parse HEAD >../../../expect
+	) &&
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--mock-git-push \
+			--rebase \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			projects/app1 \
+			>../out 2>&1
+	) &&
+	(
+		cd work/projects/app1 &&
+		git rev-parse HEAD >../../../actual &&
+		test ! -d "$(git rev-parse --git-path rebase-merge)" &&
+		test ! -d "$(git rev-parse --git-path rebase-apply)" &&
+		git status --porcelain --untracked-files=no >../../../status
+	) &&
+	test_cmp expect actual &&
+	test_must_be_empty status &&
+	grep -v "^ *[0-9a-f]\{40\}$" out >actual &&
+	cat >expect <<-EOF &&
+	Upload project projects/app1/ to remote branch Maint:
+	  branch my/topic1 ( 1 commit(s)):
+	to https://example.com (y/N)? Yes
+	NOTE: projects/app1> rebasing '"'"'my/topic1'"'"' onto '"'"'refs/remotes/aone/Maint'"'"'
+	ERROR: projects/app1> skip upload of branch '"'"'my/topic1'"'"': conflict when rebasing onto '"'"'refs/remotes/aone/Maint'"'"', rebase aborted
+	
+	----------------------------------------------------------------------
+	[FAILED] projects/app1/  my/topic1      
+	       (conflict when rebasing onto '"'"'refs/remotes/aone/Maint'"'"', rebase aborted)
+	
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "not rebase branches declined by user" '
+	push_upstream upstream-main.git upstream.txt upstream-2 &&
+	(
+		cd work/main &&
+		git rev-parse my/topic1 >../../../expect
+	) &&
+	(
+		cd work &&
+		test_must_fail git-repo upload \
+			--assume-no \
+			--no-edit \
+			--mock-git-push \
+			--rebase \
+			--re-run \
+			--br my/topic1 \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			main \
+			>../out 2>&1
+	) &&
+	test_must_fail grep "rebasing" out &&
+	(
+		cd work/main &&
+		git rev-parse my/topic1 >../../../actual
+	) &&
+	test_cmp expect actual
+'
+
+test_expect_success "not rebase in dryrun mode" '
+	(
+		cd work/main &&
+		git rev-parse my/topic1 aone/Maint >../../../expect
+	) &&
+	(
+		cd work &&
+		git-repo upload \
+			--assume-yes \
+			--no-edit \
+			--dryrun \
+			--rebase \
+			--re-run \
+			--br my/topic1 \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}" \
+			main \
+			>../out 2>&1
+	) &&
+	grep "^NOTE: main> will rebase .my/topic1. onto .refs/remotes/aone/Maint.$" out &&
+	test_must_fail grep "rebasing" out &&
+	(
+		cd work/main &&
+		git rev-parse my/topic1 aone/Maint >../../../actual
+	) &&
+	test_cmp expect actual
+'
+
+test_done
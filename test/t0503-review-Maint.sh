@@ -401,6 +401,7 @@ test_expect_success "upload to gerrit ssh review url (assume-no, dryrun, no-cach
 		Upload project (jiangxin/main) to remote branch Maint:
 		  branch jx/topic ( 1 commit(s)):
 		         <hash>
+		  ERROR: <abbrev>: missing Change-Id in commit message
 		to ssh://git@example.com:29418 (y/N)? Yes
 		NOTE: will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@example.com:29418/jiangxin/main.git refs/heads/jx/topic:refs/for/Maint
 		NOTE: will update-ref refs/published/jx/topic on refs/heads/jx/topic, reason: review from jx/topic to Maint on ssh://git@example.com:29418
@@ -412,12 +413,13 @@ test_expect_success "upload to gerrit ssh review url (assume-no, dryrun, no-cach
 			git peer-review \
 				--no-cache \
 				--assume-yes \
+				--no-verify \
 				--no-edit \
 				--dryrun \
 				--mock-ssh-info-status 500 \
 				--mock-ssh-info-response ""
 		) >out 2>&1 &&
-		sed -e "s/[0-9a-f]\{40\}/<hash>/g" <out >actual &&
+		sed -e "s/[0-9a-f]\{40\}/<hash>/g" -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" <out >actual &&
 		test_cmp expect actual
 	)
 '
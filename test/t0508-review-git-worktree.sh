@@ -540,6 +540,7 @@ test_expect_success "upload to gerrit ssh review url (assume-no, dryrun, no-cach
 		Upload project (jiangxin/main) to remote branch Maint:
 		  branch my/topic-test ( 1 commit(s)):
 		         <hash>
+		  ERROR: <abbrev>: missing Change-Id in commit message
 		to ssh://git@example.com:29418 (y/N)? Yes
 		NOTE: will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com:29418/jiangxin/main.git refs/heads/my/topic-test:refs/for/Maint
 		NOTE: will update-ref refs/published/my/topic-test on refs/heads/my/topic-test, reason: review from my/topic-test to Maint on ssh://git@example.com:29418
@@ -551,13 +552,14 @@ test_expect_success "upload to gerrit ssh review url (assume-no, dryrun, no-cach
 			git peer-review \
 				--no-cache \
 				--assume-yes \
+				--no-verify \
 				--no-edit \
 				--dryrun \
 				--mock-ssh-info-status 200 \
 				--mock-ssh-info-response \
 				"ssh.example.com 29418"
 		) >out 2>&1 &&
-		sed -e "s/[0-9a-f]\{40\}/<hash>/g" <out >actual &&
+		sed -e "s/[0-9a-f]\{40\}/<hash>/g" -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" <out >actual &&
 		test_cmp expect actual
 	)
 '
@@ -573,6 +575,7 @@ test_expect_success "upload to gerrit ssh review url (use ssh_info cache)" '
 		Upload project (jiangxin/main) to remote branch Maint:
 		  branch my/topic-test ( 1 commit(s)):
 		         <hash>
+		  ERROR: <abbrev>: missing Change-Id in commit message
 		to ssh://git@example.com:29418 (y/N)? Yes
 		NOTE: will execute command: git push --receive-pack=gerrit receive-pack ssh://committer@ssh.example.com:29418/jiangxin/main.git refs/heads/my/topic-test:refs/for/Maint
 		NOTE: will update-ref refs/published/my/topic-test on refs/heads/my/topic-test, reason: review from my/topic-test to Maint on ssh://git@example.com:29418
@@ -583,10 +586,11 @@ test_expect_success "upload to gerrit ssh review url (use ssh_info cache)" '
 			cd Maint &&
 			git peer-review \
 				--assume-yes \
+				--no-verify \
 				--no-edit \
 				--dryrun
 		) >out 2>&1 &&
-		sed -e "s/[0-9a-f]\{40\}/<hash>/g" <out >actual &&
+		sed -e "s/[0-9a-f]\{40\}/<hash>/g" -e "s/[0-9a-f]\{7\}:/<abbrev>:/g" <out >actual &&
 		test_cmp expect actual
 	)
 '
@@ -0,0 +1,196 @@
+#!/bin/sh
+
+test_description="test 'git-repo download --worktree'"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+main_repo_url="file://${REPO_TEST_REPOSITORIES}/hello/main.git"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" &&
+		git-repo start --all jx/topic
+	)
+'
+
+test_expect_success "download into default worktree" '
+	(
+		cd work &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree main 12345/1
+	) >out 2>&1 &&
+	grep "^NOTE:" out | sed -e "s#$HOME/##" >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [main] change 12345/1 is checked out in worktree work/main-review-12345-1
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main-review-12345-1 &&
+		git log --pretty="    %s" -2
+	) >actual &&
+	cat >expect<<-EOF &&
+	    New topic
+	    Version 0.1.0
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main &&
+		echo "Branch: $(git_current_branch)"
+	) >actual &&
+	cat >expect<<-EOF &&
+	Branch: jx/topic
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "download into worktree which already exists" '
+	(
+		cd work &&
+		test_must_fail git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree main 12345/1
+	) >out 2>&1 &&
+	sed -e "s#$HOME/##" out >actual &&
+	cat >expect<<-EOF &&
+	Error: worktree '"'"'work/main-review-12345-1'"'"' already exists
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "download into specific worktree" '
+	(
+		cd work &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree=../review main 12345/2
+	) >out 2>&1 &&
+	grep "^NOTE:" out | sed -e "s#$HOME/##" >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [main] change 12345/2 is checked out in worktree review
+	EOF
+	test_cmp expect actual &&
+	(
+		cd review &&
+		git log --pretty="    %s" -2
+	) >actual &&
+	cat >expect<<-EOF &&
+	    New topic
+	    Version 0.1.0
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "cannot use --worktree with --cherry-pick" '
+	(
+		cd work &&
+		test_must_fail git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree --cherry-pick main 12345/2
+	) >actual 2>&1 &&
+	cat >expect<<-\EOF &&
+	Error: cannot use --worktree with `-c`, `-r`, or `-f` options
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "cleanup review worktrees" '
+	(
+		cd work &&
+		git -C main worktree add --detach ../other HEAD >/dev/null 2>&1 &&
+		git-repo download --cleanup
+	) >out 2>&1 &&
+	sed -e "s#$HOME/##" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [main] removed worktree review
+	NOTE: [main] removed worktree work/main-review-12345-1
+	EOF
+	test_cmp expect actual &&
+	test ! -d work/main-review-12345-1 &&
+	test ! -d review &&
+	test -d work/other &&
+	(
+		cd work &&
+		git-repo download --cleanup
+	) >actual 2>&1 &&
+	cat >expect<<-EOF &&
+	NOTE: no review worktrees to remove
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "not cleanup review worktree with uncommitted changes" '
+	(
+		cd work &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree main 12345/1 &&
+		echo hack >main-review-12345-1/hack.txt &&
+		git-repo download --cleanup
+	) >out 2>&1 &&
+	grep "^WARNING:" out | sed -e "s#$HOME/##" >actual &&
+	cat >expect<<-EOF &&
+	WARNING: [main] skip worktree work/main-review-12345-1 with uncommitted changes, use --force to remove
+	EOF
+	test_cmp expect actual &&
+	test -f work/main-review-12345-1/hack.txt &&
+	(
+		cd work &&
+		git-repo download --cleanup --force
+	) >out 2>&1 &&
+	sed -e "s#$HOME/##" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [main] removed worktree work/main-review-12345-1
+	EOF
+	test_cmp expect actual &&
+	test ! -d work/main-review-12345-1
+'
+
+test_expect_success "download into worktree in single mode" '
+	git clone $main_repo_url single >/dev/null 2>&1 &&
+	(
+		cd single &&
+		git config remote.origin.review https://example.com &&
+		git-repo download --single \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree 12345/1
+	) >out 2>&1 &&
+	grep "^NOTE:" out | sed -e "s#$HOME/##" >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [single] change 12345/1 is checked out in worktree single-review-12345-1
+	EOF
+	test_cmp expect actual &&
+	(
+		cd single &&
+		git-repo download --single --cleanup
+	) >out 2>&1 &&
+	sed -e "s#$HOME/##" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [single] removed worktree single-review-12345-1
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,82 @@
+#!/bin/sh
+
+test_description="test 'git-repo download' using web URL of code review"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" &&
+		git-repo start --all jx/topic
+	)
+'
+
+test_expect_success "download using URL of gerrit change" '
+	(
+		cd work &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			https://example.com/c/main/+/12345/1
+	) &&
+	(
+		cd work/main &&
+		echo "Branch: $(git_current_branch)" &&
+		git log --pretty="    %s" -2 &&
+		git show-ref | cut -c 42- | grep changes/
+	) >out 2>&1 &&
+	sed -e "s/(no branch)/Detached HEAD/g" out >actual &&
+	cat >expect<<-EOF &&
+	Branch: Detached HEAD
+	    New topic
+	    Version 0.1.0
+	refs/changes/45/12345/1
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "download using URL of merge request into worktree" '
+	(
+		cd work &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--worktree \
+			https://example.com/main.git/merge_request/12345/2
+	) >out 2>&1 &&
+	grep "^NOTE:" out | sed -e "s#$HOME/##" >actual &&
+	cat >expect<<-EOF &&
+	NOTE: [main] change 12345/2 is checked out in worktree work/main-review-12345-2
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "cannot find project of review URL" '
+	(
+		cd work &&
+		test_must_fail git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			https://example.com/c/unknown/+/12345/1
+	) >actual 2>&1 &&
+	cat >expect<<-EOF &&
+	Error: cannot find project '"'"'unknown'"'"' of code review #12345 in workspace
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,119 @@
+#!/bin/sh
+
+test_description="test 'git-repo download --interdiff'"
+
+. lib/test-lib.sh
+
+main_repo_url="file://${REPO_TEST_REPOSITORIES}/hello/main.git"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	git clone --mirror $main_repo_url main.git &&
+	git clone main.git work &&
+	(
+		cd work &&
+		git config remote.origin.review https://example.com &&
+		git checkout -b jx/topic origin/master
+	)
+'
+
+test_expect_success "interdiff of patch sets with the same base" '
+	(
+		cd work &&
+		git-repo download --single \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--interdiff 12345/1..2
+	) >out 2>&1 &&
+	grep -v -e "^From " -e "\[new ref\]" out >actual &&
+	git -C work diff refs/changes/45/12345/1 refs/changes/45/12345/2 >expect &&
+	test -s expect &&
+	test_cmp expect actual
+'
+
+test_expect_success "push rebased patch set" '
+	(
+		cd work &&
+		git checkout -q --detach refs/changes/45/12345/2 &&
+		git rebase -q origin/master &&
+		git push -q origin HEAD:refs/changes/45/12345/3 &&
+		git checkout -q jx/topic
+	)
+'
+
+test_expect_success "interdiff of patch sets with different bases" '
+	(
+		cd work &&
+		git-repo download --single \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--interdiff 12345/2..3
+	) >out 2>&1 &&
+	grep -e "^NOTE:" -e "^1:" out | sed -e "s/[0-9a-f]\{7\}/<OID>/g" >actual &&
+	cat >expect<<-EOF &&
+	NOTE: patch set 2 and 3 have different bases, show range-diff
+	1:  <OID> = 1:  <OID> New topic
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "push patch sets of a review with two commits" '
+	(
+		cd work &&
+		git checkout -q --detach origin/master &&
+		echo one >one.txt &&
+		git add one.txt &&
+		test_tick &&
+		git commit -q -m "one" &&
+		echo two >two.txt &&
+		git add two.txt &&
+		test_tick &&
+		git commit -q -m "two" &&
+		git push -q origin HEAD:refs/changes/67/4567/1 &&
+		git tag ps1 &&
+		git checkout -q HEAD~ &&
+		echo one-v2 >one.txt &&
+		git add one.txt &&
+		test_tick &&
+		git commit -q --amend -m "one" &&
+		git cherry-pick ps1 >/dev/null &&
+		git tag -d ps1 >/dev/null &&
+		git push -q origin HEAD:refs/changes/67/4567/2 &&
+		git checkout -q jx/topic
+	)
+'
+
+test_expect_success "interdiff of patch sets with two commits and the same base" '
+	(
+		cd work &&
+		git-repo download --single \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--interdiff 4567/1..2
+	) >out 2>&1 &&
+	grep -v -e "^From " -e "\[new ref\]" out >actual &&
+	git -C work diff refs/changes/67/4567/1 refs/changes/67/4567/2 >expect &&
+	test -s expect &&
+	test_cmp expect actual
+'
+
+test_expect_success "bad interdiff" '
+	(
+		cd work &&
+		test_must_fail git-repo download --single \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--interdiff 12345/2
+	) >actual 2>&1 &&
+	cat >expect<<-EOF &&
+	Error: bad interdiff '"'"'12345/2'"'"', should be <id>/<ps1>..<ps2>
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,152 @@
+#!/bin/sh
+
+test_description="test 'git-repo sync --fetch-reviews'"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+review_response='[{"_number": 12345, "project": "main", "branch": "master",
+"status": "NEW", "current_revision": "c7c050a94bec0efff486e847ed3c78af1a6f84e8",
+"revisions": {"c7c050a94bec0efff486e847ed3c78af1a6f84e8": {"_number": 2}}}]'
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" &&
+		git-repo start --all jx/topic
+	)
+'
+
+test_expect_success "sync --fetch-reviews=all" '
+	(
+		cd work &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--mock-review-query-response "$review_response" \
+			--fetch-reviews=all
+	) >out 2>&1 &&
+	grep "^NOTE:" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: main> fetched 1 code review(s), pruned 0 code review(s)
+	NOTE: projects/app1> fetched 1 code review(s), pruned 0 code review(s)
+	NOTE: projects/app1/module1> fetched 1 code review(s), pruned 0 code review(s)
+	NOTE: projects/app2> fetched 1 code review(s), pruned 0 code review(s)
+	NOTE: drivers/driver-1> fetched 1 code review(s), pruned 0 code review(s)
+	EOF
+	test_cmp expect actual &&
+	git -C work/main show-ref | cut -c 42- | grep refs/reviews/ >actual &&
+	cat >expect<<-EOF &&
+	refs/reviews/12345/2
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "fetched code reviews are skipped" '
+	(
+		cd work &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--mock-review-query-response "$review_response" \
+			--fetch-reviews=all
+	) >out 2>&1 &&
+	test_must_fail grep "^NOTE:" out
+'
+
+test_expect_success "download offline from fetched code review" '
+	url=$(git -C work/main remote get-url aone) &&
+	test_when_finished "git -C work/main remote set-url aone $url" &&
+	(
+		cd work/main &&
+		git remote set-url aone file:///path/not/exist &&
+		git-repo download \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--cherry-pick 12345/2
+	) >out 2>&1 &&
+	grep "^WARNING:" out >actual &&
+	cat >expect<<-EOF &&
+	WARNING: main> fail to fetch code review, use '"'"'refs/reviews/12345/2'"'"' instead
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work/main &&
+		git log --pretty="    %s" -1
+	) >actual &&
+	cat >expect<<-EOF &&
+	    New topic
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "code reviews not closed are not pruned by other query" '
+	(
+		cd work &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--mock-review-query-response "[]" \
+			--fetch-reviews="owner:self" \
+			main
+	) >out 2>&1 &&
+	test_must_fail grep "^NOTE:" out &&
+	git -C work/main show-ref | cut -c 42- | grep refs/reviews/ >actual &&
+	cat >expect<<-EOF &&
+	refs/reviews/12345/2
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "prune code reviews not open" '
+	(
+		cd work &&
+		git-repo sync \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--mock-review-query-response "[]" \
+			--fetch-reviews=all \
+			main
+	) >out 2>&1 &&
+	grep "^NOTE:" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: main> fetched 0 code review(s), pruned 1 code review(s)
+	EOF
+	test_cmp expect actual &&
+	git -C work/main show-ref >actual &&
+	test_must_fail grep refs/reviews/ actual
+'
+
+test_expect_success "sync -n --fetch-reviews" '
+	(
+		cd work &&
+		git-repo sync -n \
+			--no-cache \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418" \
+			--mock-review-query-response "$review_response" \
+			--fetch-reviews=all \
+			main
+	) >out 2>&1 &&
+	grep "^NOTE:" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: main> fetched 1 code review(s), pruned 0 code review(s)
+	EOF
+	test_cmp expect actual
+'
+
+test_done
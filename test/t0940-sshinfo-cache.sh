@@ -0,0 +1,133 @@
+#!/bin/sh
+
+test_description="test 'git-repo sshinfo' to manage ssh_info cache"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}"
+	)
+'
+
+test_expect_success "list ssh_info cache" '
+	(
+		cd work &&
+		git-repo sshinfo list
+	) >out 2>&1 &&
+	sed -e "s/[0-9-]* [0-9:]*$/<EXPIRE>/" out >actual &&
+	cat >expect<<-EOF &&
+	.repo/manifests.git/info/sshinfo.cache:
+	  https://example.com                 agit     <EXPIRE>
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "show ssh_info cache" '
+	(
+		cd work &&
+		git-repo sshinfo show https://example.com/
+	) >out 2>&1 &&
+	sed -e "s/[0-9-]* [0-9:]*$/<EXPIRE>/" out >actual &&
+	cat >expect<<-\EOF &&
+	.repo/manifests.git/info/sshinfo.cache:
+	  key:     https://example.com
+	  sshinfo: {"host":"ssh.example.com","port":22,"type":"agit"}
+	  expire:  <EXPIRE>
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work &&
+		test_must_fail git-repo sshinfo show https://unknown.example.com
+	) >actual 2>&1 &&
+	cat >expect<<-\EOF &&
+	Error: no ssh_info cache for '"'"'https://unknown.example.com'"'"'
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "refresh ssh_info cache" '
+	(
+		cd work &&
+		git-repo sshinfo refresh \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response "ssh.example.com 29418"
+	) >actual 2>&1 &&
+	cat >expect<<-\EOF &&
+	https://example.com                 {"host":"ssh.example.com","port":29418,"type":"gerrit"}
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work &&
+		git-repo sshinfo list
+	) >out 2>&1 &&
+	sed -e "s/[0-9-]* [0-9:]*$/<EXPIRE>/" out >actual &&
+	cat >expect<<-EOF &&
+	.repo/manifests.git/info/sshinfo.cache:
+	  https://example.com                 gerrit   <EXPIRE>
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "negative cache only for server without ssh_info API" '
+	(
+		cd work &&
+		git config --global repo.sshInfoNegativeTTL 10m &&
+		test_must_fail git-repo sshinfo refresh \
+			--mock-ssh-info-status 503 \
+			https://down.example.com &&
+		test_must_fail git-repo sshinfo refresh \
+			--mock-ssh-info-status 404 \
+			https://noapi.example.com
+	) >actual 2>&1 &&
+	cat >expect<<-\EOF &&
+	ERROR: fail to refresh ssh_info of '"'"'https://down.example.com'"'"': 503: bad ssh_info response of '"'"'https://down.example.com/ssh_info'"'"'
+	Error: fail to refresh 1 ssh_info
+	ERROR: fail to refresh ssh_info of '"'"'https://noapi.example.com'"'"': 404: bad ssh_info response of '"'"'https://noapi.example.com/ssh_info'"'"'
+	Error: fail to refresh 1 ssh_info
+	EOF
+	test_cmp expect actual &&
+	(
+		cd work &&
+		git-repo sshinfo list
+	) >out 2>&1 &&
+	sed -e "s/[0-9-]* [0-9:]*$/<EXPIRE>/" out >actual &&
+	cat >expect<<-EOF &&
+	.repo/manifests.git/info/sshinfo.cache:
+	  https://example.com                 gerrit   <EXPIRE>
+	  https://noapi.example.com           error    <EXPIRE>
+	EOF
+	test_cmp expect actual &&
+	git config --global --unset repo.sshInfoNegativeTTL
+'
+
+test_expect_success "clear ssh_info cache" '
+	(
+		cd work &&
+		git-repo sshinfo clear https://noapi.example.com &&
+		git-repo sshinfo list &&
+		git-repo sshinfo clear &&
+		git-repo sshinfo list
+	) >out 2>&1 &&
+	sed -e "s/[0-9-]* [0-9:]*$/<EXPIRE>/" out >actual &&
+	cat >expect<<-EOF &&
+	NOTE: removed 1 ssh_info cache entries
+	.repo/manifests.git/info/sshinfo.cache:
+	  https://example.com                 gerrit   <EXPIRE>
+	NOTE: removed 1 ssh_info cache entries
+	NOTE: no ssh_info cache found
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -0,0 +1,50 @@
+#!/bin/sh
+
+test_description="test static ssh_info overrides in git config"
+
+. lib/test-lib.sh
+
+test_expect_success "ssh_info from git config" '
+	git config --global repo.https://example.com.sshinfo \
+		"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"gerrit\"}" &&
+	git config --global repo.https://example.com/team.type agit &&
+	git config --global repo.https://example.com/team.version 2 &&
+	git-repo test sshinfo https://example.com/app.git >out 2>&1 &&
+	grep -q "Host:\"ssh.example.com\"" out &&
+	grep -q "ProtoType:\"gerrit\"" out &&
+	grep "^source:" out >actual &&
+	cat >expect<<-\EOF &&
+	source: git config '"'"'repo.https://example.com'"'"'
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "longest prefix of url wins" '
+	git-repo test sshinfo https://example.com/team/app.git >out 2>&1 &&
+	grep -q "ProtoType:\"agit\"" out &&
+	grep -q "ProtoVersion:2" out &&
+	grep "^source:" out >actual &&
+	cat >expect<<-\EOF &&
+	source: git config '"'"'repo.https://example.com/team'"'"'
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "REPO_HOST_PORT_INFO overrides git config" '
+	env REPO_HOST_PORT_INFO="ssh.example.com 29418" \
+		git-repo test sshinfo https://example.com/app.git >out 2>&1 &&
+	grep "^source:" out >actual &&
+	cat >expect<<-\EOF &&
+	source: environment REPO_HOST_PORT_INFO
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "bad version in git config" '
+	git config --global repo.https://bad.example.com.type agit &&
+	git config --global repo.https://bad.example.com.version v2 &&
+	test_must_fail git-repo test sshinfo https://bad.example.com/app.git >out 2>&1 &&
+	grep "bad version in git config of" out
+'
+
+test_done
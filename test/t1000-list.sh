@@ -178,6 +178,32 @@ test_expect_success "git-repo list -g app,drivers" '
 	test_cmp expect actual
 '
 
+test_expect_success "git-repo list -g (app|drivers)&!notdefault" '
+	(
+		cd work &&
+		git-repo list -g "(app|drivers)&!notdefault"
+	) >actual &&
+	cat >expect<<-EOF &&
+	drivers/driver-1 : drivers/driver1
+	main : main
+	projects/app1 : project1
+	projects/app1/module1 : project1/module1
+	projects/app2 : project2
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "git-repo list -g with bad expression" '
+	(
+		cd work &&
+		test_must_fail git-repo list -g "(app|drivers"
+	) >actual 2>&1 &&
+	cat >expect<<-EOF &&
+	Error: invalid groups expression '"'"'(app|drivers'"'"': missing '"'"')'"'"' at position 13
+	EOF
+	test_cmp expect actual
+'
+
 test_expect_success "git-repo list -p -g app" '
 	(
 		cd work &&
@@ -150,4 +150,79 @@ test_expect_success "git repo manifest: freeze manifest --suppress-upstream-revi
 	test_cmp expect actual
 '
 
+test_expect_success "git repo manifest: output in yaml format" '
+	(
+		cd work &&
+		git-repo manifest --format yaml
+	) >actual 2>&1 &&
+	cat >expect<<-EOF &&
+	remotes:
+	- name: aone
+	  alias: origin
+	  fetch: .
+	  review: https://example.com
+	  fetch-url: file://${REPO_TEST_REPOSITORIES}/hello
+	  source-file: manifest.xml
+	- name: driver
+	  fetch: ..
+	  review: https://example.com
+	  revision: Maint
+	  fetch-url: file://${REPO_TEST_REPOSITORIES}
+	  source-file: manifest.xml
+	default:
+	  remote: aone
+	  revision: master
+	  sync-j: 4
+	projects:
+	- copyfiles:
+	  - src: VERSION
+	    dest: VERSION
+	  linkfiles:
+	  - src: Makefile
+	    dest: Makefile
+	  name: main
+	  path: main
+	  groups: app
+	  url: file://${REPO_TEST_REPOSITORIES}/hello/main.git
+	  source-file: manifest.xml
+	- name: project1
+	  path: projects/app1
+	  groups: app
+	  url: file://${REPO_TEST_REPOSITORIES}/hello/project1.git
+	  source-file: manifest.xml
+	- name: project1/module1
+	  path: projects/app1/module1
+	  revision: refs/tags/v1.0.0
+	  groups: app
+	  url: file://${REPO_TEST_REPOSITORIES}/hello/project1/module1.git
+	  source-file: manifest.xml
+	- name: project2
+	  path: projects/app2
+	  groups: app
+	  url: file://${REPO_TEST_REPOSITORIES}/hello/project2.git
+	  source-file: manifest.xml
+	- name: drivers/driver1
+	  path: drivers/driver-1
+	  remote: driver
+	  groups: drivers
+	  url: file://${REPO_TEST_REPOSITORIES}/drivers/driver1.git
+	  source-file: manifest.xml
+	- name: drivers/driver2
+	  path: drivers/driver-2
+	  remote: driver
+	  groups: notdefault,drivers
+	  url: file://${REPO_TEST_REPOSITORIES}/drivers/driver2.git
+	  source-file: manifest.xml
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "git repo manifest: unknown format" '
+	(
+		cd work &&
+		test_must_fail git-repo manifest --format toml
+	) >actual 2>&1 &&
+	grep "unknown format '"'"'toml'"'"'" actual
+'
+
 test_done
@@ -0,0 +1,132 @@
+#!/bin/sh
+
+test_description="test 'git-repo local-manifest'"
+
+. lib/test-lib.sh
+
+# Create manifest repositories
+manifest_url="file://${REPO_TEST_REPOSITORIES}/hello/manifests"
+
+test_expect_success "setup" '
+	# create .repo file as a barrier, not find .repo deeper
+	touch .repo &&
+	mkdir work &&
+	(
+		cd work &&
+		git-repo init -u $manifest_url -g all -b Maint &&
+		git-repo sync \
+			--mock-ssh-info-status 200 \
+			--mock-ssh-info-response \
+			"{\"host\":\"ssh.example.com\", \"port\":22, \"type\":\"agit\"}"
+	)
+'
+
+test_expect_success "local-manifest set-revision" '
+	(
+		cd work &&
+		git-repo local-manifest set-revision projects/app2 master
+	) &&
+	cat >expect<<-EOF &&
+	<manifest>
+	  <extend-project name="project2" path="projects/app2" revision="master"></extend-project>
+	</manifest>
+	EOF
+	test_cmp expect work/.repo/local_manifests/local.xml
+'
+
+test_expect_success "local-manifest extend-project" '
+	(
+		cd work &&
+		git-repo local-manifest extend-project --groups team main projects/app2
+	) &&
+	cat >expect<<-EOF &&
+	<manifest>
+	  <extend-project name="project2" path="projects/app2" groups="team" revision="master"></extend-project>
+	  <extend-project name="main" groups="team"></extend-project>
+	</manifest>
+	EOF
+	test_cmp expect work/.repo/local_manifests/local.xml
+'
+
+test_expect_success "local-manifest remove-project" '
+	(
+		cd work &&
+		git-repo local-manifest remove-project drivers/driver-2 main
+	) &&
+	cat >expect<<-EOF &&
+	<manifest>
+	  <remove-project name="drivers/driver2" path="drivers/driver-2"></remove-project>
+	  <remove-project name="main"></remove-project>
+	  <extend-project name="project2" path="projects/app2" groups="team" revision="master"></extend-project>
+	</manifest>
+	EOF
+	test_cmp expect work/.repo/local_manifests/local.xml
+'
+
+test_expect_success "local-manifest add-project" '
+	(
+		cd work &&
+		git-repo local-manifest add-project --path projects/app3 \
+			--revision master project2
+	) &&
+	cat >expect<<-EOF &&
+	<manifest>
+	  <project name="project2" path="projects/app3" revision="master"></project>
+	  <remove-project name="drivers/driver2" path="drivers/driver-2"></remove-project>
+	  <remove-project name="main"></remove-project>
+	  <extend-project name="project2" path="projects/app2" groups="team" revision="master"></extend-project>
+	</manifest>
+	EOF
+	test_cmp expect work/.repo/local_manifests/local.xml
+'
+
+test_expect_success "local-manifest add-project: bad remote, duplicate path or removed" '
+	cp work/.repo/local_manifests/local.xml expect &&
+	(
+		cd work &&
+		test_must_fail git-repo local-manifest add-project \
+			--remote bad-remote project3 &&
+		test_must_fail git-repo local-manifest add-project \
+			--path projects/app1 project3 &&
+		test_must_fail git-repo local-manifest add-project \
+			--path apps/main main
+	) &&
+	test_cmp expect work/.repo/local_manifests/local.xml
+'
+
+test_expect_success "local-manifest list" '
+	(
+		cd work &&
+		git-repo local-manifest list
+	) >actual &&
+	cat >expect<<-EOF &&
+	local_manifests/local.xml
+	  project         project2 path=projects/app3 revision=master
+	  remove-project  drivers/driver2 path=drivers/driver-2
+	  remove-project  main
+	  extend-project  project2 path=projects/app2 groups=team revision=master
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "merged manifest" '
+	(
+		cd work &&
+		git-repo manifest
+	) >actual &&
+	cat >expect<<-EOF &&
+	<manifest>
+	  <remote name="aone" alias="origin" fetch="." review="https://example.com"></remote>
+	  <remote name="driver" fetch=".." review="https://example.com"></remote>
+	  <default remote="aone" revision="Maint" sync-j="4"></default>
+	  <project name="project1" path="projects/app1" groups="app"></project>
+	  <project name="project1/module1" path="projects/app1/module1" revision="refs/tags/v0.2.0" groups="app"></project>
+	  <project name="project2" path="projects/app2" revision="master" groups="app,team"></project>
+	  <project name="drivers/driver1" path="drivers/driver-1" remote="driver" groups="drivers"></project>
+	  <project name="project2" path="projects/app3" revision="master"></project>
+	</manifest>
+	EOF
+	test_cmp expect actual
+'
+
+test_done
@@ -47,7 +47,7 @@ test_expect_success "upload command (SSH protocol, verison 0)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -96,7 +96,7 @@ test_expect_success "upload command (SSH protocol, verison 2)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --version 2 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 2 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
@@ -146,7 +146,7 @@ test_expect_success "upload command (SSH protocol, draft, version 2)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --version 2 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 2 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
@@ -196,7 +196,7 @@ test_expect_success "upload command (HTTP protocol, version 0)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
@@ -244,7 +244,7 @@ test_expect_success "upload command (SSH protocol with code review ID, version 0
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -293,7 +293,7 @@ test_expect_success "upload command (SSH protocol with code review ID, version 2
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --version 2 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 2 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
@@ -345,7 +345,7 @@ test_expect_success "upload command (SSH protocol with code review ID, version 3
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --version 3 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 3 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 
 	test_cmp expect actual
@@ -396,14 +396,30 @@ test_expect_success "upload command (HTTP protocol with code review ID, draft)"
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type agit --version 2 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 2 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
 
+test_expect_success "upload command (topic as session, unsupported options)" '
+	cat <<-EOF |
+	{
+	  "DestBranch": "master",
+	  "Hashtags": ["h1"],
+	  "LocalBranch": "my/topic",
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Topic": "feature-x"
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type agit --version 2 --upload >actual 2>&1 &&
+	grep "\"refs/heads/my/topic:refs/for/master/feature-x\"" actual &&
+	test_must_fail grep "h1" actual
+'
+
 test_expect_success "download MR 123456 (agit-v2)" '
 	printf "12345\n" | \
-	git-repo helper proto --type agit --version 2 --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 2 --download >actual 2>&1 &&
 	cat >expect <<-EOF &&
 		refs/merge-requests/12345/head
 	EOF
@@ -412,7 +428,7 @@ test_expect_success "download MR 123456 (agit-v2)" '
 
 test_expect_success "download CR 123 (agit-v3)" '
 	printf "123\n" | \
-	git-repo helper proto --type agit --version 3 --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 3 --download >actual 2>&1 &&
 	cat >expect <<-EOF &&
 		-o review=123
 		refs/changes/123/head
@@ -422,7 +438,7 @@ test_expect_success "download CR 123 (agit-v3)" '
 
 test_expect_success "download CR 123/2 (agit-v3)" '
 	printf "123/2\n" | \
-	git-repo helper proto --type agit --version 3 --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type agit --version 3 --download >actual 2>&1 &&
 	cat >expect <<-EOF &&
 		-o review=123
 		refs/changes/123/2
@@ -37,7 +37,7 @@ test_expect_success "upload command (SSH protocol)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -73,7 +73,7 @@ test_expect_success "upload command (SSH protocol, draft)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -109,7 +109,7 @@ test_expect_success "upload command (HTTP protocol)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -138,7 +138,7 @@ test_expect_success "upload command (SSH protocol with code review ID)" '
 	  "Version": 1
 	}
 	EOF
-	test_must_fail git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	test_must_fail git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -167,7 +167,41 @@ test_expect_success "upload command (HTTP protocol with code review ID, draft)"
 	  "Version": 1
 	}
 	EOF
-	test_must_fail git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	test_must_fail git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"cmd": "git",
+	"args": [
+		"push",
+		"--receive-pack=gerrit receive-pack",
+		"origin",
+		"refs/heads/my/topic:refs/for/master%r=u1,topic=feature-x,hashtag=h1,hashtag=h2,l=Code-Review+1,l=Verified+1,m=Rebased+on+master%2C+fixed+typo,publish-comments"
+	]
+}
+EOF
+
+test_expect_success "upload command (topic, hashtags, labels, message and publish-comments)" '
+	cat <<-EOF |
+	{
+	  "AutoTopic": true,
+	  "DestBranch": "master",
+	  "Hashtags": ["h1", "h2"],
+	  "Labels": ["Code-Review+1", "Verified+1"],
+	  "LocalBranch": "my/topic",
+	  "Message": "Rebased on master, fixed typo",
+	  "People":[
+		["u1"]
+	  ],
+	  "PublishComments": true,
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com:29418/test/repo.git",
+	  "Topic": "feature-x"
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type gerrit --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -178,7 +212,7 @@ EOF
 
 test_expect_success "download ref (no patch)" '
 	printf "12345\n" | \
-	git-repo helper proto --type gerrit --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type gerrit --download >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -188,7 +222,7 @@ EOF
 
 test_expect_success "download ref (with patch)" '
 	printf "12345 2\n" | \
-	git-repo helper proto --type gerrit --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type gerrit --download >actual 2>&1 &&
 	test_cmp expect actual
 '
 
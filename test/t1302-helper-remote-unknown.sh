@@ -1,6 +1,6 @@
 #!/bin/sh
 
-test_description="git-repo helper proto --type agit"
+test_description="git-repo helper proto --type <external-helper>"
 
 . lib/test-lib.sh
 
@@ -21,7 +21,19 @@ test_expect_success "setup" '
 
 		git-repo helper proto --type gerrit "\$@"
 		EOF
-		chmod a+x git-repo-helper-proto-unknown2
+		chmod a+x git-repo-helper-proto-unknown2 &&
+		cat >git-repo-helper-proto-legacy <<-EOF &&
+		#!/bin/sh
+
+		case "\$1" in
+		--capabilities)
+			echo >&2 "unknown flag: \$1"
+			exit 1
+			;;
+		esac
+		git-repo helper proto --protocol 1 --type agit "\$@"
+		EOF
+		chmod a+x git-repo-helper-proto-legacy
 	)
 '
 
@@ -68,7 +80,7 @@ test_expect_success "upload command (SSH protocol, version 0)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type unknown1 --upload >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type unknown1 --upload >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -117,7 +129,7 @@ test_expect_success "upload command (SSH protocol, version 2)" '
 	  "Version": 1
 	}
 	EOF
-	git-repo helper proto --type unknown1 --version 2 --upload >out 2>&1 &&
+	git-repo helper proto --protocol 1 --type unknown1 --version 2 --upload >out 2>&1 &&
 	sed -e "s/git-repo\/[^ \"\\]*/git-repo\/n.n.n.n/g" <out >actual &&
 	test_cmp expect actual
 '
@@ -128,17 +140,60 @@ EOF
 
 test_expect_success "download ref" '
 	printf "12345\n" | \
-	git-repo helper proto --type unknown2 --download >actual 2>&1 &&
+	git-repo helper proto --protocol 1 --type unknown2 --download >actual 2>&1 &&
 	test_cmp expect actual
 '
 
+test_expect_success "upload command (legacy helper, protocol version 1)" '
+	cat <<-EOF |
+	{
+	  "Description": "description of code review",
+	  "DestBranch": "master",
+	  "Issue": "123",
+	  "LocalBranch": "my/topic",
+	  "People":[
+		["u1", "u2"],
+		["u3", "u4"]
+	  ],
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Title": "title of code review"
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type legacy --upload >out 2>&1 &&
+	grep "\"refs/heads/my/topic:refs/for/master/my/topic\"" out &&
+	grep "\"--receive-pack=agit-receive-pack\"" out
+'
+
+cat >expect <<EOF
+-o review=123
+refs/changes/123/2
+EOF
+
+test_expect_success "download ref with options (protocol version 2)" '
+	printf "123/2\n" | \
+	git-repo helper proto --protocol 1 --type unknown1 --version 3 --download >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+test_expect_success "download ref with options (legacy helper, protocol version 1)" '
+	printf "123/2\n" | \
+	git-repo helper proto --protocol 1 --type legacy --version 3 --download >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+test_expect_success "show error of helper" '
+	printf "abc\n" | \
+	test_must_fail git-repo helper proto --protocol 1 --type unknown1 --download >actual 2>&1 &&
+	grep "^Error: fail to run git-repo-helper-proto-unknown1: Error: bad review ID" actual
+'
+
 cat >expect <<EOF
 Error: cannot find helper 'git-repo-helper-proto-unknown3'
 EOF
 
 test_expect_success "cannot find helper program" '
 	printf "12345\n" | \
-	test_must_fail git-repo helper proto --type unknown3 --download >actual 2>&1 &&
+	test_must_fail git-repo helper proto --protocol 1 --type unknown3 --download >actual 2>&1 &&
 	test_cmp expect actual
 '
 
@@ -0,0 +1,175 @@
+#!/bin/sh
+
+test_description="git-repo helper proto --type gitlab"
+
+. lib/test-lib.sh
+
+cat >expect <<EOF
+{
+	"cmd": "git",
+	"args": [
+		"push",
+		"-o",
+		"merge_request.create",
+		"-o",
+		"merge_request.target=master",
+		"-o",
+		"merge_request.title=title of code review",
+		"-o",
+		"merge_request.description=description of code review\u003cbr\u003e\u003cbr\u003emore details",
+		"-o",
+		"merge_request.assign=u1",
+		"-o",
+		"merge_request.assign=u2",
+		"origin",
+		"+refs/heads/my/topic:refs/heads/worldhello.net/my/topic"
+	]
+}
+EOF
+
+test_expect_success "upload command (SSH protocol)" '
+	cat <<-EOF |
+	{
+	  "CodeReview": {"ID": "", "Ref": ""},
+	  "Description": "description of code review\n\nmore details",
+	  "DestBranch": "master",
+	  "Draft": false,
+	  "LocalBranch": "my/topic",
+	  "People":[
+		["u1", "u2"]
+	  ],
+	  "ProjectName": "test/repo",
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Title": "title of code review",
+	  "UserEmail": "Jiang Xin <worldhello.net@gmail.com>",
+	  "Version": 1
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type gitlab --upload >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"cmd": "git",
+	"args": [
+		"push",
+		"-o",
+		"ci.skip",
+		"-o",
+		"merge_request.create",
+		"-o",
+		"merge_request.target=maint",
+		"-o",
+		"merge_request.draft",
+		"-o",
+		"merge_request.remove_source_branch",
+		"https://example.com/test/repo.git",
+		"+refs/heads/my/topic:refs/heads/my/topic"
+	]
+}
+EOF
+
+test_expect_success "upload command (HTTP protocol, draft, remove source branch)" '
+	cat <<-EOF |
+	{
+	  "CodeReview": {"ID": "", "Ref": ""},
+	  "DestBranch": "refs/heads/maint",
+	  "Draft": true,
+	  "LocalBranch": "refs/heads/my/topic",
+	  "PushOptions": ["ci.skip"],
+	  "RemoteURL": "https://example.com/test/repo.git",
+	  "RemoveSourceBranch": true,
+	  "Version": 1
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type gitlab --upload >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"cmd": "git",
+	"args": [
+		"push",
+		"-o",
+		"merge_request.create",
+		"-o",
+		"merge_request.target=master",
+		"-o",
+		"merge_request.label=h1",
+		"-o",
+		"merge_request.label=h2",
+		"origin",
+		"+refs/heads/my/topic:refs/heads/worldhello.net/feature-x"
+	]
+}
+EOF
+
+test_expect_success "upload command (topic as source branch, hashtags as labels)" '
+	cat <<-EOF |
+	{
+	  "DestBranch": "master",
+	  "Hashtags": ["h1", "h2"],
+	  "LocalBranch": "my/topic",
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Topic": "feature-x",
+	  "UserEmail": "Jiang Xin <worldhello.net@gmail.com>"
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type gitlab --upload >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+test_expect_success "upload command with cc (warning)" '
+	cat <<-EOF |
+	{
+	  "DestBranch": "master",
+	  "LocalBranch": "my/topic",
+	  "People":[
+		[],
+		["u3", "u4"]
+	  ],
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Version": 1
+	}
+	EOF
+	git-repo helper proto --protocol 1 --type gitlab --upload >out 2>&1 &&
+	grep "^WARNING: cc is not supported by gitlab, ignored: u3,u4" out
+'
+
+test_expect_success "cannot change merge request by ID" '
+	cat <<-EOF |
+	{
+	  "CodeReview": {"ID": "12345", "Ref": "refs/merge-requests/12345/head"},
+	  "DestBranch": "master",
+	  "LocalBranch": "my/topic",
+	  "RemoteName": "origin",
+	  "RemoteURL": "ssh://git@example.com/test/repo.git",
+	  "Version": 1
+	}
+	EOF
+	test_must_fail git-repo helper proto --protocol 1 --type gitlab --upload >out 2>&1 &&
+	grep "cannot change merge request 12345 directly" out
+'
+
+test_expect_success "download MR 12345" '
+	printf "12345\n" | \
+	git-repo helper proto --protocol 1 --type gitlab --download >actual 2>&1 &&
+	cat >expect <<-EOF &&
+		refs/merge-requests/12345/head
+	EOF
+	test_cmp expect actual
+'
+
+test_expect_success "download MR 12345/2 (patch ignored)" '
+	printf "12345/2\n" | \
+	git-repo helper proto --protocol 1 --type gitlab --download >out 2>&1 &&
+	grep "^refs/merge-requests/12345/head$" out &&
+	grep "patch set 2 is ignored" out
+'
+
+test_done
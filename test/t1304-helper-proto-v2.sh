@@ -0,0 +1,109 @@
+#!/bin/sh
+
+test_description="git-repo helper proto (protocol version 2)"
+
+. lib/test-lib.sh
+
+cat >expect <<EOF
+{
+	"protocol": 2,
+	"capabilities": {
+		"protocol": 2,
+		"upload": true,
+		"download": true,
+		"draft": true,
+		"private": true,
+		"wip": true,
+		"topic": true,
+		"stacked": true
+	}
+}
+EOF
+
+test_expect_success "capabilities of agit" '
+	git-repo helper proto --type agit --capabilities </dev/null >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"protocol": 2,
+	"capabilities": {
+		"protocol": 2,
+		"upload": true,
+		"download": true,
+		"draft": true,
+		"wip": true,
+		"topic": true,
+		"hashtag": true
+	}
+}
+EOF
+
+test_expect_success "capabilities of gitlab" '
+	git-repo helper proto --type gitlab --capabilities </dev/null >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"protocol": 2,
+	"push": {
+		"cmd": "git",
+		"args": [
+			"push",
+			"--receive-pack=gerrit receive-pack",
+			"origin",
+			"refs/heads/my/topic:refs/for/master%r=u1,cc=u3"
+		]
+	}
+}
+EOF
+
+test_expect_success "upload command" '
+	cat <<-EOF |
+	{
+	  "protocol": 2,
+	  "type": "gerrit",
+	  "upload": {
+	    "DestBranch": "master",
+	    "LocalBranch": "my/topic",
+	    "People":[
+	      ["u1"],
+	      ["u3"]
+	    ],
+	    "RemoteName": "origin",
+	    "RemoteURL": "ssh://git@example.com:29418/test/repo.git"
+	  }
+	}
+	EOF
+	git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+test_expect_success "upload command without upload options" '
+	printf "{\"protocol\": 2}" | \
+	test_must_fail git-repo helper proto --type gerrit --upload >actual 2>&1 &&
+	cat >expect <<-EOF &&
+	Error: no upload options in request
+	EOF
+	test_cmp expect actual
+'
+
+cat >expect <<EOF
+{
+	"protocol": 2,
+	"ref": "refs/changes/123/2",
+	"options": [
+		"review=123"
+	]
+}
+EOF
+
+test_expect_success "download ref" '
+	printf "{\"protocol\": 2, \"download\": {\"id\": \"123\", \"patch\": \"2\"}}" | \
+	git-repo helper proto --type agit --version 3 --download >actual 2>&1 &&
+	test_cmp expect actual
+'
+
+test_done
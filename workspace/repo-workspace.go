@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/alibaba/git-repo-go/cap"
+	"github.com/alibaba/git-repo-go/common"
 	"github.com/alibaba/git-repo-go/config"
 	"github.com/alibaba/git-repo-go/errors"
 	"github.com/alibaba/git-repo-go/file"
@@ -269,6 +270,9 @@ func (v RepoWorkSpace) GetProjects(o *GetProjectsOptions, args ...string) ([]*pr
 			groups = "default,platform-" + runtime.GOOS
 		}
 	}
+	if err := project.ValidateGroups(groups); err != nil {
+		return nil, err
+	}
 
 	if len(args) == 0 {
 		allProjects = v.Projects
@@ -381,6 +385,62 @@ func (v *RepoWorkSpace) FreezeManifest(fillUpstream bool) error {
 	return v.Manifest.ProjectHandle(handle)
 }
 
+type resolveProject struct {
+	WorkSpace *RepoWorkSpace
+}
+
+func (v *resolveProject) Process(mp *manifest.Project, parentDir string) error {
+	if parentDir == "" {
+		parentDir = mp.Path
+	} else {
+		parentDir = filepath.Join(parentDir, mp.Path)
+	}
+
+	mp.SourceFile = v.WorkSpace.relSourceFile(mp.SourceFile)
+	p := v.WorkSpace.GetProjectWithPath(parentDir)
+	if p == nil {
+		log.Warnf("cannot find project '%s' to resolve", parentDir)
+		return nil
+	}
+	mp.URL = p.RemoteURL
+	return nil
+}
+
+// relSourceFile returns path of manifest file relative to the admin dir.
+func (v *RepoWorkSpace) relSourceFile(name string) string {
+	if name == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(v.AdminDir(), name)
+	if err != nil {
+		return name
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ResolveManifest fills fields of manifest which are not defined in XML,
+// such as URLs of remotes and projects, and these fields are used to output
+// manifest in JSON or YAML format.
+func (v *RepoWorkSpace) ResolveManifest() error {
+	if v.Manifest == nil {
+		return nil
+	}
+	for i := range v.Manifest.Remotes {
+		r := &v.Manifest.Remotes[i]
+		r.SourceFile = v.relSourceFile(r.SourceFile)
+		if r.Fetch == "" || v.ManifestURL() == "" {
+			continue
+		}
+		u, err := common.URLJoin(v.ManifestURL(), r.Fetch)
+		if err != nil {
+			log.Warnf("fail to resolve fetch URL of remote '%s': %s", r.Name, err)
+			continue
+		}
+		r.FetchURL = u
+	}
+	return v.Manifest.ProjectHandle(&resolveProject{WorkSpace: v})
+}
+
 // UpdateProjectList updates `project.list` file and try to remove obsolete projects.
 func (v *RepoWorkSpace) UpdateProjectList(submodulesOK bool) ([]string, error) {
 	var (
@@ -0,0 +1,104 @@
+package workspace
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alibaba/git-repo-go/common"
+	"github.com/alibaba/git-repo-go/config"
+	"github.com/alibaba/git-repo-go/manifest"
+	"github.com/alibaba/git-repo-go/project"
+	log "github.com/jiangxin/multi-log"
+)
+
+// Superproject returns the superproject defined in manifest, which is a bare
+// repository saved in ".repo/superproject/".
+func (v *RepoWorkSpace) Superproject() (*project.Project, error) {
+	if v.Manifest == nil || v.Manifest.Superproject == nil {
+		return nil, fmt.Errorf("no superproject defined in manifest")
+	}
+
+	sp := v.Manifest.Superproject
+	mp := manifest.Project{
+		Name:       sp.Name,
+		Path:       sp.Name,
+		RemoteName: sp.RemoteName,
+		Revision:   sp.Revision,
+	}
+	if mp.RemoteName == "" && v.Manifest.Default != nil {
+		mp.RemoteName = v.Manifest.Default.RemoteName
+	}
+	for i := range v.Manifest.Remotes {
+		if v.Manifest.Remotes[i].Name == mp.RemoteName {
+			mp.ManifestRemote = &v.Manifest.Remotes[i]
+			break
+		}
+	}
+	if mp.ManifestRemote == nil {
+		return nil, fmt.Errorf("cannot find remote '%s' for superproject '%s'",
+			mp.RemoteName,
+			sp.Name)
+	}
+	if mp.Revision == "" {
+		mp.Revision = mp.ManifestRemote.Revision
+	}
+
+	p := project.NewMirrorProject(&mp, v.Settings(), v.Manifest)
+	p.GitDir = filepath.Join(v.AdminDir(), config.Superproject, sp.Name+".git")
+	p.ObjectsGitDir = p.GitDir
+	return p, nil
+}
+
+// UpdateRevisionsFromSuperproject fetches superproject, and changes revisions
+// of projects to commits recorded in the superproject. Projects can be fetched
+// in one round without resolving their branches one by one. Superproject
+// is not fetched if o is nil.
+func (v *RepoWorkSpace) UpdateRevisionsFromSuperproject(projects []*project.Project, o *project.FetchOptions) error {
+	sp, err := v.Superproject()
+	if err != nil {
+		return err
+	}
+	if sp.Revision == "" {
+		return fmt.Errorf("no revision for superproject '%s'", sp.Name)
+	}
+
+	if o != nil {
+		fetchOptions := *o
+		fetchOptions.CurrentBranchOnly = true
+		fetchOptions.NoTags = true
+		if !sp.Exists() {
+			sp.GitInit()
+		}
+		err = sp.Repository.Fetch(sp.RemoteName, &fetchOptions)
+		if err != nil {
+			return err
+		}
+	} else if !sp.Exists() {
+		return fmt.Errorf("superproject '%s' is not fetched yet", sp.Name)
+	}
+
+	revision := sp.Revision
+	if !common.IsSha(revision) && !common.IsTag(revision) && !common.IsHead(revision) {
+		revision = config.RefsHeads + revision
+	}
+	gitlinks, err := sp.GitLinks(revision)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		revid, ok := gitlinks[p.Path]
+		if !ok {
+			log.Debugf("%snot found in superproject, use revision %s", p.Prompt(), p.Revision)
+			continue
+		}
+		// Keep the original revision as upstream for branch tracking.
+		if p.Upstream == "" && !common.IsImmutable(p.Revision) {
+			p.Upstream = p.Revision
+		}
+		log.Debugf("%schange revision from %s to %s using superproject",
+			p.Prompt(), p.Revision, revid)
+		p.Revision = revid
+	}
+	return nil
+}